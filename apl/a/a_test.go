@@ -0,0 +1,93 @@
+package a
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/numbers"
+	"github.com/ktye/iv/apl/operators"
+	"github.com/ktye/iv/apl/primitives"
+)
+
+func TestCmd(t *testing.T) {
+	a := apl.New(nil)
+	numbers.Register(a)
+	primitives.Register(a)
+	operators.Register(a)
+	Register(a, "")
+
+	testCases := []struct {
+		in, exp string
+	}{
+		{"`sq a→cmd \"{⍵×⍵}\"", "sq"},
+		{"/sq 3", "9"},
+		{"/sq/sq 2", "16"},
+		{`("dbl";"/dbl N";"double N";) a→cmd "2×"`, "dbl"},
+		{"/dbl 1 2", "2 4"},
+		{"(a→cmd 0)[`dbl]", "/dbl N"},
+		{"(a→cmd 0)[`sq]", "/sq ARGS"},
+	}
+	for _, tc := range testCases {
+		var b strings.Builder
+		a.SetOutput(&b)
+		if err := a.ParseAndEval(tc.in); err != nil {
+			t.Fatalf("%s: %s", tc.in, err)
+		}
+		if got := strings.TrimSpace(b.String()); got != tc.exp {
+			t.Fatalf("%s: expected %q got %q", tc.in, tc.exp, got)
+		}
+	}
+
+	var b strings.Builder
+	a.SetOutput(&b)
+	if err := a.ParseAndEval("/h"); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"/dbl N    double N", "/t EXPR   time the expression"} {
+		if strings.Contains(b.String(), s) == false {
+			t.Fatalf("help does not contain %q", s)
+		}
+	}
+}
+
+func TestParallel(t *testing.T) {
+	a := apl.New(nil)
+	numbers.Register(a)
+	primitives.Register(a)
+	operators.Register(a)
+	Register(a, "")
+
+	testCases := []struct {
+		in, exp string
+	}{
+		{"(a→c 0)[`workers`threshold]", "workers:   1\nthreshold: 100000"},
+		{"V←÷⍳10000⋄(+/V)≡{⍺+⍵}/V", "1"}, // sequential reduction is not split into chunks
+		{"(a→c `workers`threshold#4 2)[`workers`threshold]", "workers:   4\nthreshold: 2"},
+		{"+/2 3⍴⍳6", "6 15"},
+		{"+/[1]2 3⍴⍳6", "5 7 9"},
+		{",(3 2⍴⍳6)+.×2 4⍴⍳8", "11 14 17 20 23 30 37 44 35 46 57 68"},
+		{"2×⍳12", "2 4 6 8 10 12 14 16 18 20 22 24"},
+		{"+/⍳10000", "50005000"},
+		{"⌈/10000⍴⍳7", "7"},
+		{"-/⍳10000", "¯5000"},
+	}
+	for _, tc := range testCases {
+		var b strings.Builder
+		a.SetOutput(&b)
+		if err := a.ParseAndEval(tc.in); err != nil {
+			t.Fatalf("%s: %s", tc.in, err)
+		}
+		if got := strings.TrimSpace(b.String()); got != tc.exp {
+			t.Fatalf("%s: expected %q got %q", tc.in, tc.exp, got)
+		}
+	}
+	if a.Parallel.Workers != 4 || a.Parallel.Threshold != 2 {
+		t.Fatalf("settings are not applied: %+v", a.Parallel)
+	}
+	for _, s := range []string{"a→c `workers#¯1", "a→c `size#1"} {
+		if err := a.ParseAndEval(s); err == nil {
+			t.Fatalf("%s: expected error", s)
+		}
+	}
+}
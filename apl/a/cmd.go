@@ -0,0 +1,64 @@
+package a
+
+import (
+	"fmt"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/scan"
+)
+
+// defineCmd defines a scanner command in APL.
+// R is the APL source which replaces the command name, the arguments are appended.
+// L is the command name, or a vector of name, usage and help text.
+// Example:
+//	`sq a→cmd "{⍵×⍵}"
+//	/sq 3        ⍝ is rewritten to {⍵×⍵} 3
+// Called monadically, it returns a dictionary of all commands with their usage.
+func defineCmd(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L == nil {
+		d := apl.Dict{M: make(map[apl.Value]apl.Value)}
+		for _, c := range a.Commands() {
+			k := apl.String(c.Name())
+			d.K = append(d.K, k)
+			d.M[k] = apl.String(c.Usage())
+		}
+		return &d, nil
+	}
+
+	src, ok := R.(apl.String)
+	if ok == false {
+		return nil, fmt.Errorf("a cmd: right argument must be a string: %T", R)
+	}
+	var s [3]string
+	if name, ok := L.(apl.String); ok {
+		s[0] = string(name)
+	} else if ar, ok := L.(apl.Array); ok && ar.Size() <= 3 {
+		for i := 0; i < ar.Size(); i++ {
+			str, ok := ar.At(i).(apl.String)
+			if ok == false {
+				return nil, fmt.Errorf("a cmd: left argument must contain strings: %T", ar.At(i))
+			}
+			s[i] = string(str)
+		}
+	} else {
+		return nil, fmt.Errorf("a cmd: left argument must be a name or name, usage and help")
+	}
+	if s[0] == "" {
+		return nil, fmt.Errorf("a cmd: command name is empty")
+	}
+	if s[1] == "" {
+		s[1] = "/" + s[0] + " ARGS"
+	}
+	if s[2] == "" {
+		s[2] = string(src) + " ARGS"
+	}
+
+	prefix, err := a.Scan(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("a cmd: %s", err)
+	}
+	a.AddCommands(scan.NewCommand(s[0], s[1], s[2], func(t []scan.Token, _ scan.Options) ([]scan.Token, error) {
+		return append(append([]scan.Token{}, prefix...), t...), nil
+	}))
+	return apl.String(s[0]), nil
+}
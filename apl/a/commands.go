@@ -8,30 +8,23 @@ import (
 	"github.com/ktye/iv/apl/scan"
 )
 
-// toCommand attaches the Rewrite method to the function.
-type toCommand func([]scan.Token) []scan.Token
-
-func (f toCommand) Rewrite(t []scan.Token) []scan.Token {
-	return f(t)
-}
-
 func symbol(s string) scan.Token {
 	return scan.Token{T: scan.Symbol, S: s}
 }
 
-// rw0 is a scan.Command that rewrite the symbol with a 0 argument.
+// rw0 returns a rewrite function that calls the function with a 0 argument.
 // Example:
 //	/q	is rewritten to a→q 0
-type rw0 string
-
-func (r rw0) Rewrite(t []scan.Token) []scan.Token {
-	sym := scan.Token{T: scan.Identifier, S: "a→" + string(r)}
-	num := scan.Token{T: scan.Number, S: "0"}
-	tokens := make([]scan.Token, len(t)+2)
-	tokens[0] = sym
-	tokens[1] = num
-	copy(tokens[2:], t)
-	return tokens
+func rw0(name string) scan.RewriteFunc {
+	return func(t []scan.Token, _ scan.Options) ([]scan.Token, error) {
+		sym := scan.Token{T: scan.Identifier, S: "a→" + name}
+		num := scan.Token{T: scan.Number, S: "0"}
+		tokens := make([]scan.Token, len(t)+2)
+		tokens[0] = sym
+		tokens[1] = num
+		copy(tokens[2:], t)
+		return tokens, nil
+	}
 }
 
 // printvar prints a string representation of the value.
@@ -49,8 +42,8 @@ func printvar(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
 	return apl.String(v.String(a.Format)), nil
 }
 
-func printCmd(t []scan.Token) []scan.Token {
-	return append([]scan.Token{scan.Token{T: scan.Identifier, S: "a→p"}}, t...)
+func printCmd(t []scan.Token, _ scan.Options) ([]scan.Token, error) {
+	return append([]scan.Token{scan.Token{T: scan.Identifier, S: "a→p"}}, t...), nil
 }
 
 // Timer is used to time an expression. It is called by the rewrite command /t
@@ -68,7 +61,7 @@ func timer(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
 
 // timeCmd rewrites the tokens to calculate the duration.
 //	T__← a→t 0 ⋄ [TOKENS] ⋄ a→t T__
-func timeCmd(t []scan.Token) []scan.Token {
+func timeCmd(t []scan.Token, _ scan.Options) ([]scan.Token, error) {
 	t__ := scan.Token{T: scan.Identifier, S: "T__"}
 	asn := symbol("←")
 	tim := scan.Token{T: scan.Identifier, S: "a→t"}
@@ -77,5 +70,5 @@ func timeCmd(t []scan.Token) []scan.Token {
 
 	tokens := []scan.Token{t__, asn, tim, num, dia}
 	tokens = append(tokens, t...)
-	return append(tokens, dia, tim, t__)
+	return append(tokens, dia, tim, t__), nil
 }
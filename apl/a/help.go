@@ -4,18 +4,27 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/ktye/iv/apl"
 )
 
 // help returns the help text in a channel.
+// It lists all commands with their usage, followed by the documentation of all primitives.
 func help(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
 	var buf bytes.Buffer
-	fmt.Fprintf(&buf, "Commands:")
-	for _, c := range a.Scanner.Commands() {
-		fmt.Fprintf(&buf, " %s", c)
+	fmt.Fprintf(&buf, "Commands:\n")
+	tw := tabwriter.NewWriter(&buf, 1, 0, 2, ' ', 0)
+	for _, c := range a.Commands() {
+		usage := c.Usage()
+		if o := c.Options(); len(o) > 0 {
+			usage += " [-" + strings.Join(o, " -") + "]"
+		}
+		fmt.Fprintf(tw, "\t%s\t%s\n", usage, c.Help())
 	}
-	fmt.Fprintf(&buf, "\n\n")
+	tw.Flush()
+	fmt.Fprintf(&buf, "\n")
 
 	a.Doc(&buf)
 	return apl.LineReader(ioutil.NopCloser(&buf)), nil
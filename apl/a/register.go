@@ -3,10 +3,14 @@
 // Some of the functions defined return information about the go runtime.
 // This includes the parent application if the interpreter is built-in.
 //
-//	c 0 return number of CPUs
+//	c 0    return number of CPUs and parallel execution settings as a dictionary
+//	c D    set parallel execution settings workers and threshold from dictionary D
 //	g 0    return number of go routines
 //	m 0    return runtime.MemStats as a dictionary
 //	v 0    return go version
+//
+// Scanner commands such as /h are listed by a→h.
+// New commands can be defined in APL with a→cmd.
 package a
 
 import (
@@ -23,22 +27,22 @@ func Register(p *apl.Apl, name string) {
 		name = "a"
 	}
 	pkg := map[string]apl.Value{
-		"c": apl.ToFunction(cpus),
-		"g": apl.ToFunction(goroutines),
-		"h": apl.ToFunction(help),
-		"m": apl.ToFunction(Memstats),
-		"p": apl.ToFunction(printvar),
-		"q": apl.ToFunction(quit),
-		"t": apl.ToFunction(timer),
-		"v": apl.ToFunction(goversion),
-	}
-	cmd := map[string]scan.Command{
-		"h": rw0("h"),
-		"p": toCommand(printCmd),
-		"q": rw0("q"),
-		"t": toCommand(timeCmd),
+		"c":   apl.ToFunction(cpus),
+		"cmd": apl.ToFunction(defineCmd),
+		"g":   apl.ToFunction(goroutines),
+		"h":   apl.ToFunction(help),
+		"m":   apl.ToFunction(Memstats),
+		"p":   apl.ToFunction(printvar),
+		"q":   apl.ToFunction(quit),
+		"t":   apl.ToFunction(timer),
+		"v":   apl.ToFunction(goversion),
 	}
-	p.AddCommands(cmd)
+	p.AddCommands(
+		scan.NewCommand("h", "/h", "show help", rw0("h")),
+		scan.NewCommand("p", "/p R", "print R, the definition of a lambda if R is a name", printCmd),
+		scan.NewCommand("q", "/q", "quit", rw0("q")),
+		scan.NewCommand("t", "/t EXPR", "time the expression", timeCmd),
+	)
 	p.RegisterPackage(name, pkg)
 }
 
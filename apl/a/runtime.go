@@ -1,6 +1,7 @@
 package a
 
 import (
+	"fmt"
 	"reflect"
 	"runtime"
 
@@ -15,8 +16,41 @@ func Memstats(p *apl.Apl, L, R apl.Value) (apl.Value, error) {
 	return xgo.Convert(reflect.ValueOf(m))
 }
 
+// cpus returns the number of CPUs and the settings for parallel execution as a dict:
+//	cpu		number of CPUs
+//	workers		maximum number of goroutines for parallel execution
+//	threshold	minimum number of elements for parallel execution
+// If R is a dict, workers and threshold are set before.
 func cpus(p *apl.Apl, _, R apl.Value) (apl.Value, error) {
-	return apl.Int(runtime.NumCPU()), nil
+	if d, ok := R.(apl.Object); ok {
+		par := p.Parallel
+		for _, k := range d.Keys() {
+			n, ok := d.At(k).(apl.Number)
+			if ok == false {
+				return nil, fmt.Errorf("a c: %s must be a number", k.String(p.Format))
+			}
+			i, ok := n.ToIndex()
+			if ok == false || i < 0 {
+				return nil, fmt.Errorf("a c: %s must be a positive integer", k.String(p.Format))
+			}
+			switch k {
+			case apl.String("workers"):
+				par.Workers = i
+			case apl.String("threshold"):
+				par.Threshold = i
+			default:
+				return nil, fmt.Errorf("a c: unknown key: %s", k.String(p.Format))
+			}
+		}
+		p.Parallel = par
+	}
+	d := apl.Dict{K: []apl.Value{apl.String("cpu"), apl.String("workers"), apl.String("threshold")}}
+	d.M = map[apl.Value]apl.Value{
+		d.K[0]: apl.Int(runtime.NumCPU()),
+		d.K[1]: apl.Int(p.Parallel.Workers),
+		d.K[2]: apl.Int(p.Parallel.Threshold),
+	}
+	return &d, nil
 }
 
 func goroutines(p *apl.Apl, _, R apl.Value) (apl.Value, error) {
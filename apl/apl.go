@@ -5,6 +5,7 @@ import (
 	"io"
 	"io/ioutil"
 	"reflect"
+	"sync"
 
 	"github.com/ktye/iv/apl/scan"
 )
@@ -12,16 +13,19 @@ import (
 // New starts a new interpreter.
 func New(w io.Writer) *Apl {
 	a := Apl{
-		stdout: w,
-		env:    newEnv(),
-		Origin: 1,
-		Format: Format{Fmt: make(map[reflect.Type]string)},
+		stdout:   w,
+		env:      newEnv(),
+		envmu:    new(sync.RWMutex),
+		Origin:   1,
+		Parallel: DefaultParallel,
+		Format:   Format{Fmt: make(map[reflect.Type]string)},
 		//PP:         0,
 		//Fmt:        make(map[reflect.Type]string),
 		primitives: make(map[Primitive][]PrimitiveHandler),
 		operators:  make(map[string][]Operator),
 		symbols:    make(map[rune]string),
 		pkg:        make(map[string]*env),
+		state:      make(map[string]interface{}),
 	}
 	a.parser.a = &a
 	return &a
@@ -32,17 +36,20 @@ type Apl struct {
 	scan.Scanner
 	Format Format
 	parser
-	stdout io.Writer
-	stdimg ImageWriter
-	Tower  Tower
-	Origin int
+	stdout   io.Writer
+	stdimg   ImageWriter
+	Tower    Tower
+	Origin   int
+	Parallel Parallel
 	//PP         int
 	//Fmt        map[reflect.Type]string
 	env        *env
+	envmu      *sync.RWMutex // envmu protects the variables and packages, it is shared with forks.
 	primitives map[Primitive][]PrimitiveHandler
 	operators  map[string][]Operator
 	symbols    map[rune]string
 	pkg        map[string]*env
+	state      map[string]interface{}
 	scaninit   bool
 }
 
@@ -51,6 +58,22 @@ type Format struct {
 	Fmt map[reflect.Type]string
 }
 
+// Fork returns a copy of the interpreter, that can be used concurrently with a.
+// It shares the registered primitives, operators, packages, the output and the variables.
+// Lambda calls on the fork do not change the environment of a.
+// Access to the shared variables is synchronized.
+func (a *Apl) Fork() *Apl {
+	b := *a
+	b.Scanner = scan.Scanner{}
+	b.AddCommands(a.Commands()...)
+	for k, t := range a.Idioms() {
+		b.AddIdiom(k, t)
+	}
+	b.scaninit = false
+	b.parser = parser{a: &b}
+	return &b
+}
+
 // LoadPkg loads a package from a file.
 // It temporarily removes the current environment, executes the package file with EvalFile
 // and stores the resulting environment in a package with the name of pkg.
@@ -65,7 +88,9 @@ func (a *Apl) LoadPkg(r io.Reader, file string, pkg string) (err error) {
 	if err != nil {
 		return err
 	}
+	a.envmu.Lock()
 	a.pkg[pkg] = a.env
+	a.envmu.Unlock()
 	return nil
 }
 
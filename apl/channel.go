@@ -16,6 +16,7 @@ import (
 //	f/C	reduce over channel
 //	f\C	scan over channel
 //	[L]f¨C	each channel
+//	[L]f¨[n]C	parallel each with n workers, f¨[n 1]C preserves the order
 type Channel [2]chan Value
 
 // TODO: drain input channels.
@@ -152,12 +153,13 @@ func LineReader(rc io.ReadCloser) Channel {
 	scn := bufio.NewScanner(rc)
 	c := NewChannel()
 	go func(c Channel) {
+	loop:
 		for scn.Scan() {
 			line := scn.Text()
 			select {
 			case _, ok := <-c[1]:
 				if ok == false {
-					break
+					break loop
 				}
 			case c[0] <- String(line):
 			}
@@ -1,5 +1,11 @@
 package apl
 
+import (
+	"fmt"
+
+	"github.com/ktye/iv/apl/scan"
+)
+
 // Error carries an error value.
 // It is used by go routines to signal errors.
 // To send err over Channel c, use: c[0]<-Error{e}
@@ -14,3 +20,38 @@ func (e Error) String(f Format) string {
 	return e.E.Error()
 }
 func (e Error) Copy() Value { return e }
+
+// position is the location of a token.
+type position struct {
+	pos, line int
+}
+
+func tokenPos(t scan.Token) position {
+	return position{t.Pos, t.Line}
+}
+
+// wrap adds the position to the error.
+// An error that already carries a position is not changed.
+func (p position) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(scan.PosError); ok {
+		return err
+	}
+	return scan.PosError{Pos: p.pos, Line: p.line, Err: err}
+}
+
+// errorf returns a formatted error at the position.
+func (p position) errorf(format string, args ...interface{}) error {
+	return p.wrap(fmt.Errorf(format, args...))
+}
+
+// unwrapPos removes the position from an error.
+// It is used for errors of a lambda function, whose positions refer to it's definition.
+func unwrapPos(err error) error {
+	if e, ok := err.(scan.PosError); ok {
+		return e.Err
+	}
+	return err
+}
@@ -6,6 +6,8 @@ import (
 	"io"
 	"runtime/debug"
 	"strings"
+
+	"github.com/ktye/iv/apl/scan"
 )
 
 // Program contains a slice of parsed expressions.
@@ -86,9 +88,15 @@ func (p Program) String(f Format) string {
 // The file argument is used only in the error message.
 func (a *Apl) EvalFile(r io.Reader, file string) (err error) {
 	line := 0
+	var lines []string // lines of the current statement
 	defer func() {
 		if err != nil {
-			err = fileError{file: file, line: line, err: err}
+			f := fileError{file: file, line: line, err: err}
+			if e, ok := err.(scan.PosError); ok && e.Line < len(lines) {
+				f.line = line - len(lines) + 1 + e.Line
+				f.col = e.Column(lines[e.Line])
+			}
+			err = f
 		}
 	}()
 
@@ -98,6 +106,10 @@ func (a *Apl) EvalFile(r io.Reader, file string) (err error) {
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line++
+		if b.Len() == 0 {
+			lines = lines[:0]
+		}
+		lines = append(lines, scanner.Text())
 		ok, err = b.Add(scanner.Text())
 		if err != nil {
 			return
@@ -124,10 +136,14 @@ func (a *Apl) EvalFile(r io.Reader, file string) (err error) {
 type fileError struct {
 	file string
 	line int
+	col  int
 	err  error
 }
 
 func (f fileError) Error() string {
+	if f.col > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", f.file, f.line, f.col, f.err.Error())
+	}
 	return fmt.Sprintf("%s:%d: %s", f.file, f.line, f.err.Error())
 }
 
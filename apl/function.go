@@ -28,11 +28,16 @@ type function struct {
 	Function
 	left, right expr
 	selection   bool
+	pos         position
 }
 
 // Eval calls the function with it's surrounding arugments.
-func (f *function) Eval(a *Apl) (Value, error) {
-	var err error
+// Errors are located at the position of the function.
+func (f *function) Eval(a *Apl) (v Value, err error) {
+	defer func() {
+		err = f.pos.wrap(err)
+	}()
+
 	var l, r Value
 
 	// The right argument must be evaluated first.
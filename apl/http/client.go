@@ -0,0 +1,157 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+// doRequest sends an http request, that is described by the dict R with the keys:
+//	url	string (required)
+//	method	string, default GET
+//	header	dict of header values
+//	body	string, other values are sent as json
+//	timeout	number of seconds for the request including reading the body
+//	as	"string", "lines" or "json": how the response body is returned
+// The result is a dict with the keys:
+//	status	status code
+//	header	dict of header values
+//	body	string, channel of lines or decoded json
+// If "as" is missing, a json response is decoded and others are returned as a string.
+// A status code that is not 2xx is not an error.
+func doRequest(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	d, ok := R.(apl.Object)
+	if ok == false {
+		return nil, fmt.Errorf("http request: argument must be a dict: %T", R)
+	}
+	opt := make(map[string]apl.Value)
+	for _, k := range d.Keys() {
+		s, ok := k.(apl.String)
+		if ok == false {
+			return nil, fmt.Errorf("http request: keys must be strings")
+		}
+		switch s {
+		case "url", "method", "header", "body", "timeout", "as":
+			opt[string(s)] = d.At(k)
+		default:
+			return nil, fmt.Errorf("http request: unknown key: %s", s)
+		}
+	}
+	str := func(key, def string) (string, error) {
+		v, ok := opt[key]
+		if ok == false {
+			return def, nil
+		}
+		s, ok := v.(apl.String)
+		if ok == false {
+			return "", fmt.Errorf("http request: %s must be a string: %T", key, v)
+		}
+		return string(s), nil
+	}
+
+	url, err := str("url", "")
+	if err != nil {
+		return nil, err
+	} else if url == "" {
+		return nil, fmt.Errorf("http request: url is missing")
+	}
+	method, err := str("method", "GET")
+	if err != nil {
+		return nil, err
+	}
+	as, err := str("as", "")
+	if err != nil {
+		return nil, err
+	} else if as != "" && as != "string" && as != "lines" && as != "json" {
+		return nil, fmt.Errorf("http request: as must be string, lines or json: %s", as)
+	}
+
+	var body io.Reader
+	jsonBody := false
+	if v, ok := opt["body"]; ok {
+		if s, ok := v.(apl.String); ok {
+			body = strings.NewReader(string(s))
+		} else if s, err := apl.Primitive("⍕").Call(a, apl.String("json"), v); err != nil {
+			return nil, fmt.Errorf("http request: body: %s", err)
+		} else {
+			body = strings.NewReader(string(s.(apl.String)))
+			jsonBody = true
+		}
+	}
+	req, err := http.NewRequest(strings.ToUpper(method), url, body)
+	if err != nil {
+		return nil, err
+	}
+	if h, ok := opt["header"]; ok {
+		o, ok := h.(apl.Object)
+		if ok == false {
+			return nil, fmt.Errorf("http request: header must be a dict: %T", h)
+		}
+		for _, k := range o.Keys() {
+			v := o.At(k)
+			if sa, ok := v.(apl.StringArray); ok {
+				for _, s := range sa.Strings {
+					req.Header.Add(k.String(a.Format), s)
+				}
+			} else if s, ok := v.(apl.String); ok {
+				req.Header.Set(k.String(a.Format), string(s))
+			} else {
+				req.Header.Set(k.String(a.Format), v.String(a.Format))
+			}
+		}
+	}
+	if jsonBody && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	var client http.Client
+	if t, ok := opt["timeout"]; ok {
+		switch n := t.(type) {
+		case apl.Int:
+			client.Timeout = time.Duration(n) * time.Second
+		case numbers.Float:
+			client.Timeout = time.Duration(float64(n) * float64(time.Second))
+		default:
+			return nil, fmt.Errorf("http request: timeout must be a number of seconds: %T", t)
+		}
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if as == "" {
+		as = "string"
+		if strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+			as = "json"
+		}
+	}
+	var v apl.Value
+	switch as {
+	case "lines":
+		v = apl.LineReader(res.Body)
+	case "json":
+		defer res.Body.Close()
+		if v, err = decodeJSON(a, res.Body); err != nil {
+			return nil, err
+		}
+	default:
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		v = apl.String(b)
+	}
+	return dict([]string{"status", "header", "body"}, []apl.Value{
+		apl.Int(res.StatusCode),
+		values(res.Header),
+		v,
+	}), nil
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ktye/iv/apl"
+)
+
+func TestRequest(t *testing.T) {
+	a := newApl()
+	for _, s := range []string{
+		"http→handle (\"/echo\";{⍵[`body]};)",
+		"http→handle (\"/method\";{⍵[`method]};)",
+		"http→handle (\"/header\";{H←⍵[`header]⋄H[`Token]};)",
+	} {
+		if err := a.ParseAndEval(s); err != nil {
+			t.Fatalf("%s: %s", s, err)
+		}
+	}
+	srv := httptest.NewServer(Handler(a))
+	defer srv.Close()
+
+	b := newApl()
+	testCases := []struct {
+		req, exp string
+	}{
+		{"`url#\"@/method\"", "R[`status]:200"},
+		{"`url`method#(\"@/method\";\"put\";)", "R[`body]:PUT"},
+		{"`url`header#(\"@/header\";`Token#\"abc\";)", "R[`body]:abc"},
+		{"`url`method`body#(\"@/echo\";\"POST\";\"text\";)", "R[`body]:text"},
+		{"`url`method`body`header#(\"@/echo\";\"POST\";`a`b#1 2;`Accept#\"application/json\";)", "B[`b]:2"},
+		{"`url`method`body`header`as#(\"@/echo\";\"POST\";`a`b#1 2;`Accept#\"application/json\";\"string\";)", "R[`body]:{\"a\":1,\"b\":2}"},
+		{"`url`method`body`as#(\"@/echo\";\"POST\";\"alpha\";\"lines\";)", "↑R[`body]:alpha"},
+		{"`url#\"@/missing\"", "R[`status]:404"},
+	}
+	for _, tc := range testCases {
+		if err := b.ParseAndEval("R←http→request " + strings.Replace(tc.req, "@", srv.URL, 1)); err != nil {
+			t.Fatalf("%s: %s", tc.req, err)
+		}
+		b.ParseAndEval("B←R[`body]")
+		i := strings.Index(tc.exp, ":")
+		if err := b.ParseAndEval("X←" + tc.exp[:i]); err != nil {
+			t.Fatalf("%s: %s", tc.req, err)
+		}
+		if got := b.Lookup("X").String(b.Format); got != tc.exp[i+1:] {
+			t.Fatalf("%s: expected %s got %s", tc.req, tc.exp[i+1:], got)
+		}
+	}
+
+	// The timeout includes reading the body.
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer slow.Close()
+	b.Assign("U", apl.String(slow.URL))
+	if err := b.ParseAndEval("http→request `url`timeout#(U;0.05;)"); err == nil {
+		t.Fatal("expected timeout")
+	}
+	for _, s := range []string{
+		"http→request `method#\"GET\"",
+		"http→request `url`what#(U;1;)",
+		"http→request `url`as#(U;\"xml\";)",
+	} {
+		if err := b.ParseAndEval(s); err == nil {
+			t.Fatalf("%s: expected error", s)
+		}
+	}
+}
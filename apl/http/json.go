@@ -0,0 +1,130 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ktye/iv/apl"
+)
+
+// decodeJSON decodes a json document.
+//
+// Objects become dicts with the keys in document order.
+// An array of objects with the same keys becomes a table,
+// other arrays are unified, if possible.
+// Numbers are parsed by the numeric tower, null is the empty array.
+func decodeJSON(a *apl.Apl, r io.Reader) (apl.Value, error) {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	v, err := jsonValue(a, d)
+	if err != nil {
+		return nil, fmt.Errorf("json: %s", err)
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, fmt.Errorf("json: unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+func jsonValue(a *apl.Apl, d *json.Decoder) (apl.Value, error) {
+	t, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := t.(type) {
+	case json.Delim:
+		if v == '{' {
+			return jsonObject(a, d)
+		} else if v == '[' {
+			return jsonArray(a, d)
+		}
+		return nil, fmt.Errorf("unexpected %s", v)
+	case bool:
+		return apl.Bool(v), nil
+	case json.Number:
+		n, err := a.Tower.Parse(strings.Replace(string(v), "-", "¯", -1))
+		if err != nil {
+			return nil, err
+		}
+		return n.Number, nil
+	case string:
+		return apl.String(v), nil
+	case nil:
+		return apl.EmptyArray{}, nil
+	}
+	return nil, fmt.Errorf("unexpected token %v", t)
+}
+
+func jsonObject(a *apl.Apl, d *json.Decoder) (apl.Value, error) {
+	dict := apl.Dict{M: make(map[apl.Value]apl.Value)}
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := apl.String(t.(string))
+		v, err := jsonValue(a, d)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := dict.M[key]; ok == false {
+			dict.K = append(dict.K, key)
+		}
+		dict.M[key] = v
+	}
+	if _, err := d.Token(); err != nil { // }
+		return nil, err
+	}
+	return &dict, nil
+}
+
+func jsonArray(a *apl.Apl, d *json.Decoder) (apl.Value, error) {
+	var values []apl.Value
+	for d.More() {
+		v, err := jsonValue(a, d)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	if _, err := d.Token(); err != nil { // ]
+		return nil, err
+	}
+	if len(values) == 0 {
+		return apl.EmptyArray{}, nil
+	}
+	if t, ok := jsonTable(a, values); ok {
+		return t, nil
+	}
+	return a.UnifyArray(apl.MixedArray{Dims: []int{len(values)}, Values: values}), nil
+}
+
+// jsonTable converts a list of dicts with the same keys to a table.
+func jsonTable(a *apl.Apl, values []apl.Value) (apl.Table, bool) {
+	first, ok := values[0].(*apl.Dict)
+	if ok == false {
+		return apl.Table{}, false
+	}
+	for _, v := range values[1:] {
+		d, ok := v.(*apl.Dict)
+		if ok == false || len(d.K) != len(first.K) {
+			return apl.Table{}, false
+		}
+		for i := range d.K {
+			if d.K[i] != first.K[i] {
+				return apl.Table{}, false
+			}
+		}
+	}
+	t := apl.Dict{K: first.K, M: make(map[apl.Value]apl.Value)}
+	for _, k := range first.K {
+		col := apl.MixedArray{Dims: []int{len(values)}, Values: make([]apl.Value, len(values))}
+		for i, v := range values {
+			col.Values[i] = v.(*apl.Dict).M[k]
+		}
+		t.M[k] = a.UnifyArray(col)
+	}
+	return apl.Table{Dict: &t, Rows: len(values)}, true
+}
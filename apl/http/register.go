@@ -7,9 +7,15 @@ import (
 	"github.com/ktye/iv/apl"
 )
 
+// Register adds the http package to the interpreter.
+// See README.md
 func Register(a *apl.Apl, name string) {
 	pkg := map[string]apl.Value{
-		"get": apl.ToFunction(get),
+		"get":     apl.ToFunction(get),
+		"request": apl.ToFunction(doRequest),
+		"handle":  apl.ToFunction(handle),
+		"serve":   apl.ToFunction(serve),
+		"close":   apl.ToFunction(closeServer),
 	}
 	if name == "" {
 		name = "http"
@@ -0,0 +1,258 @@
+package http
+
+import (
+	"fmt"
+	"image/png"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ktye/iv/apl"
+)
+
+// Handler returns the http handler of the interpreter.
+// It serves the routes registered with http→handle.
+//
+// Each request calls the function of the route with the request dict as the right argument.
+// Requests are executed concurrently, each on a fork of the interpreter,
+// which shares the variables with a.
+func Handler(a *apl.Apl) http.Handler {
+	return routes(a)
+}
+
+// router maps paths to APL functions.
+// A path ending with a slash matches all paths below, the longest match wins.
+// The interpreter a is a fork, that is only used to create a fork for each request,
+// the original interpreter may be in use at the same time.
+type router struct {
+	mu     sync.RWMutex
+	a      *apl.Apl
+	routes map[string]apl.Function
+}
+
+func routes(a *apl.Apl) *router {
+	if r, ok := a.State("http").(*router); ok {
+		return r
+	}
+	r := &router{a: a.Fork(), routes: make(map[string]apl.Function)}
+	a.SetState("http", r)
+	return r
+}
+
+func (rt *router) handle(path string, f apl.Function) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if f == nil {
+		delete(rt.routes, path)
+	} else {
+		rt.routes[path] = f
+	}
+}
+
+// match returns the function for the path.
+func (rt *router) match(path string) apl.Function {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	if f, ok := rt.routes[path]; ok {
+		return f
+	}
+	var best string
+	for p := range rt.routes {
+		if strings.HasSuffix(p, "/") && strings.HasPrefix(path, p) && len(p) > len(best) {
+			best = p
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return rt.routes[best]
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f := rt.match(r.URL.Path)
+	if f == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	a := rt.a.Fork()
+	req, err := request(a, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	v, err := f.Call(a, nil, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := respond(a, w, r, v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// request converts the http request to a dict with the keys:
+//	method	string
+//	path	string
+//	query	dict of query parameters
+//	header	dict of header values
+//	body	decoded body
+// The body is decoded depending on the Content-Type:
+// json becomes a dict, table or array, form values a dict of strings and others a string.
+// Parameters with multiple values are string arrays.
+func request(a *apl.Apl, r *http.Request) (apl.Value, error) {
+	var body apl.Value = apl.String("")
+	ct := r.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(ct, "application/json"):
+		v, err := decodeJSON(a, r.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = v
+	case strings.HasPrefix(ct, "application/x-www-form-urlencoded"):
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		body = values(r.PostForm)
+	default:
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = apl.String(b)
+	}
+	return dict([]string{"method", "path", "query", "header", "body"}, []apl.Value{
+		apl.String(r.Method),
+		apl.String(r.URL.Path),
+		values(r.URL.Query()),
+		values(r.Header),
+		body,
+	}), nil
+}
+
+// values converts url values or a header to a dict with sorted keys.
+func values(m map[string][]string) *apl.Dict {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	v := make([]apl.Value, len(keys))
+	for i, k := range keys {
+		if s := m[k]; len(s) == 1 {
+			v[i] = apl.String(s[0])
+		} else {
+			v[i] = apl.StringArray{Dims: []int{len(s)}, Strings: s}
+		}
+	}
+	return dict(keys, v)
+}
+
+func dict(keys []string, values []apl.Value) *apl.Dict {
+	d := apl.Dict{K: make([]apl.Value, len(keys)), M: make(map[apl.Value]apl.Value)}
+	for i, k := range keys {
+		d.K[i] = apl.String(k)
+		d.M[d.K[i]] = values[i]
+	}
+	return &d
+}
+
+// respond writes the value v.
+// An image is encoded as png.
+// Other values are formatted as json or csv, if the client accepts it, or as text.
+func respond(a *apl.Apl, w http.ResponseWriter, r *http.Request, v apl.Value) error {
+	if img, ok := v.(apl.Image); ok {
+		w.Header().Set("Content-Type", "image/png")
+		return png.Encode(w, img.Image)
+	}
+	ct, format := "text/plain; charset=utf-8", ""
+	accept := r.Header.Get("Accept")
+	if strings.Contains(accept, "application/json") {
+		ct, format = "application/json", "json"
+	} else if strings.Contains(accept, "text/csv") {
+		ct, format = "text/csv", "csv"
+	}
+
+	var s apl.Value
+	if format != "" {
+		var err error
+		if s, err = apl.Primitive("⍕").Call(a, apl.String(format), v); err != nil {
+			return err
+		}
+	} else if str, ok := v.(apl.String); ok {
+		s = str
+	} else {
+		s = apl.String(v.String(a.Format))
+	}
+	str, ok := s.(apl.String)
+	if ok == false {
+		return fmt.Errorf("http: cannot format %T", v)
+	}
+	w.Header().Set("Content-Type", ct)
+	_, err := w.Write([]byte(str))
+	return err
+}
+
+// Server is an http server started by http→serve.
+type Server struct {
+	*http.Server
+	ln net.Listener
+}
+
+func (s Server) String(f apl.Format) string {
+	return fmt.Sprintf("http server on %s", s.ln.Addr())
+}
+func (s Server) Copy() apl.Value { return s }
+
+// serve starts an http server for the interpreter's handler on the address R.
+// It returns the server, which can be stopped with http→close.
+func serve(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	addr, ok := R.(apl.String)
+	if ok == false {
+		return nil, fmt.Errorf("http serve: argument must be an address string")
+	}
+	ln, err := net.Listen("tcp", string(addr))
+	if err != nil {
+		return nil, err
+	}
+	s := Server{Server: &http.Server{Handler: Handler(a)}, ln: ln}
+	go s.Serve(ln)
+	return s, nil
+}
+
+// closeServer stops the server R.
+func closeServer(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	s, ok := R.(Server)
+	if ok == false {
+		return nil, fmt.Errorf("http close: argument must be a server: %T", R)
+	}
+	if err := s.Close(); err != nil {
+		return nil, err
+	}
+	return apl.Int(1), nil
+}
+
+// handle registers a function for a route: http→handle ("/path";f;)
+// If the function is missing, the route is removed.
+func handle(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	l, ok := R.(apl.List)
+	if ok == false || len(l) < 1 || len(l) > 2 {
+		return nil, fmt.Errorf("http handle: argument must be a list (path;function;)")
+	}
+	path, ok := l[0].(apl.String)
+	if ok == false || strings.HasPrefix(string(path), "/") == false {
+		return nil, fmt.Errorf("http handle: path must be a string starting with /")
+	}
+	var f apl.Function
+	if len(l) == 2 {
+		if f, ok = l[1].(apl.Function); ok == false {
+			return nil, fmt.Errorf("http handle: second list element must be a function: %T", l[1])
+		}
+	}
+	routes(a).handle(string(path), f)
+	return apl.EmptyArray{}, nil
+}
@@ -0,0 +1,202 @@
+package http
+
+import (
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/numbers"
+	"github.com/ktye/iv/apl/operators"
+	"github.com/ktye/iv/apl/primitives"
+)
+
+func newApl() *apl.Apl {
+	var b strings.Builder
+	a := apl.New(&b)
+	numbers.Register(a)
+	primitives.Register(a)
+	operators.Register(a)
+	Register(a, "")
+	return a
+}
+
+func TestServer(t *testing.T) {
+	a := newApl()
+	for _, s := range []string{
+		"http→handle (\"/sum\";{Q←⍵[`query]⋄+/⍎¨Q[`x]};)",
+		"http→handle (\"/echo\";{⍵[`body]};)",
+		"http→handle (\"/method\";{⍵[`method]};)",
+		"http→handle (\"/table/\";{⍉`a`b#(1 2;3 4;)};)",
+		"http→handle (\"/img\";{`img ⌶2 3⍴0xFF0000};)",
+		`http→handle ("/fail";{1+"x"};)`,
+		`http→handle ("/add";{X←http→handle ("/added";{"new"};)⋄"ok"};)`,
+	} {
+		if err := a.ParseAndEval(s); err != nil {
+			t.Fatalf("%s: %s", s, err)
+		}
+	}
+	srv := httptest.NewServer(Handler(a))
+	defer srv.Close()
+
+	testCases := []struct {
+		method, path, ctype, accept, body string
+		status                            int
+		exp                               string
+	}{
+		{"GET", "/sum?x=1&x=2&x=3", "", "", "", 200, "6"},
+		{"GET", "/sum?x=1&x=2&x=3", "", "application/json", "", 200, "6"},
+		{"POST", "/method", "", "", "", 200, "POST"},
+		{"POST", "/echo", "application/json", "application/json", `{"a":[1,2.5,-3e2],"b":"x"}`, 200, `{"a":[1,2.5,-300],"b":"x"}`},
+		{"POST", "/echo", "application/json", "", `[{"a":1,"b":"x"},{"a":2,"b":"y"}]`, 200, "a b\n1 x\n2 y\n"},
+		{"POST", "/echo", "application/x-www-form-urlencoded", "application/json", "u=alpha&v=1&v=2", 200, `{"u":"alpha","v":["1","2"]}`},
+		{"POST", "/echo", "text/plain", "", "plain text", 200, "plain text"},
+		{"POST", "/echo", "application/json", "", `{"a":`, 400, ""},
+		{"GET", "/table/x", "", "text/csv", "", 200, "a,b\n1,3\n2,4\n"},
+		{"GET", "/table/x", "", "application/json", "", 200, `{"a":[1,2],"b":[3,4]}`},
+		{"GET", "/missing", "", "", "", 404, ""},
+		{"GET", "/fail", "", "", "", 500, ""},
+		{"GET", "/add", "", "", "", 200, "ok"},
+		{"GET", "/added", "", "", "", 200, "new"},
+	}
+	for _, tc := range testCases {
+		req, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.ctype != "" {
+			req.Header.Set("Content-Type", tc.ctype)
+		}
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tc.status {
+			t.Fatalf("%s %s: expected status %d got %d: %s", tc.method, tc.path, tc.status, res.StatusCode, b)
+		}
+		if tc.status == 200 && string(b) != tc.exp {
+			t.Fatalf("%s %s: expected %q got %q", tc.method, tc.path, tc.exp, b)
+		}
+	}
+
+	// Images are encoded as png.
+	res, err := http.Get(srv.URL + "/img")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "image/png" {
+		t.Fatalf("img: content type %s", ct)
+	}
+	m, err := png.Decode(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := m.Bounds(); b.Dx() != 3 || b.Dy() != 2 {
+		t.Fatalf("img: bounds %v", b)
+	}
+}
+
+func TestServe(t *testing.T) {
+	a := newApl()
+	if err := a.ParseAndEval(`http→handle ("/";{"hello"};)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ParseAndEval(`S←http→serve "127.0.0.1:0"`); err != nil {
+		t.Fatal(err)
+	}
+	s := a.Lookup("S").(Server)
+	res, err := http.Get("http://" + s.ln.Addr().String() + "/x/y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != "hello" {
+		t.Fatalf("got %q", b)
+	}
+
+	// Requests are served while the interpreter is in use.
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 20; i++ {
+			res, err := http.Get("http://" + s.ln.Addr().String() + "/")
+			if err != nil {
+				done <- err
+				return
+			}
+			res.Body.Close()
+		}
+		done <- nil
+	}()
+	for i := 0; i < 20; i++ {
+		if err := a.ParseAndEval(`{⍵+1}¨⍳10`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ParseAndEval(`http→close S`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.Get("http://" + s.ln.Addr().String() + "/"); err == nil {
+		t.Fatal("server should be closed")
+	}
+}
+
+// A slow handler does not block other requests.
+func TestConcurrent(t *testing.T) {
+	a := newApl()
+	started, wait := make(chan struct{}), make(chan struct{})
+	a.RegisterPackage("t", map[string]apl.Value{
+		"wait": apl.ToFunction(func(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+			close(started)
+			<-wait
+			return R, nil
+		}),
+	})
+	for _, s := range []string{
+		`http→handle ("/slow";{t→wait "slow"};)`,
+		`http→handle ("/fast";{"fast"};)`,
+	} {
+		if err := a.ParseAndEval(s); err != nil {
+			t.Fatalf("%s: %s", s, err)
+		}
+	}
+	srv := httptest.NewServer(Handler(a))
+	defer srv.Close()
+
+	get := func(path string) string {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			return err.Error()
+		}
+		defer res.Body.Close()
+		b, _ := ioutil.ReadAll(res.Body)
+		return string(b)
+	}
+	slow := make(chan string)
+	go func() { slow <- get("/slow") }()
+	<-started
+	if s := get("/fast"); s != "fast" {
+		t.Fatalf("fast: got %q", s)
+	}
+	close(wait)
+	if s := <-slow; s != "slow" {
+		t.Fatalf("slow: got %q", s)
+	}
+}
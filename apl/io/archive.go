@@ -0,0 +1,191 @@
+package io
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// archive is the protocol for zip and tar files.
+// The root given to mount is an os path, relative to the current directory of the interpreter:
+//	/m "zip://logs.zip" /z
+//	/m "tar:///data/logs.tar.gz" /t
+// Archives are mounted read-only.
+type archive struct {
+	m    *mtab
+	kind string
+}
+
+func (p archive) FileSystem(root string) (FileSystem, error) {
+	file := p.m.abs(root)
+	if p.kind == "zip" {
+		r, err := zip.OpenReader(file)
+		if err != nil {
+			return nil, err
+		}
+		z := zipfs{path: file, ReadCloser: r, dir: make(dirIndex)}
+		for _, f := range r.File {
+			z.dir.add(f.Name)
+		}
+		return z, nil
+	}
+
+	t := tarfs{path: file, dir: make(dirIndex)}
+	err := t.walk(func(h *tar.Header) {
+		name := h.Name
+		if h.Typeflag == tar.TypeDir && strings.HasSuffix(name, "/") == false {
+			name += "/"
+		}
+		t.dir.add(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// zipfs is a mounted zip file.
+type zipfs struct {
+	*zip.ReadCloser
+	path string
+	dir  dirIndex
+}
+
+func (z zipfs) String() string { return "zip://" + z.path }
+
+func (z zipfs) Open(name, mpt string) (io.ReadCloser, error) {
+	if rc, ok := z.dir.list(name, mpt); ok {
+		return rc, nil
+	}
+	for _, f := range z.File {
+		if f.Name == name {
+			return f.Open()
+		}
+	}
+	return nil, notFound(name)
+}
+
+// tarfs is a mounted tar file, which may be compressed.
+// The archive is read sequentially each time a file is opened.
+type tarfs struct {
+	path string
+	dir  dirIndex
+}
+
+func (t tarfs) String() string { return "tar://" + t.path }
+
+func (t tarfs) Open(name, mpt string) (io.ReadCloser, error) {
+	if rc, ok := t.dir.list(name, mpt); ok {
+		return rc, nil
+	}
+	rc, r, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			rc.Close()
+			return nil, notFound(name)
+		} else if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		if strings.TrimPrefix(h.Name, "./") == name {
+			return readCloser{r, rc}, nil
+		}
+	}
+}
+
+// walk calls f for each entry of the archive.
+func (t tarfs) walk(f func(*tar.Header)) error {
+	rc, r, err := t.open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		f(h)
+	}
+}
+
+// open returns a tar reader and the underlying file.
+func (t tarfs) open() (io.ReadCloser, *tar.Reader, error) {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := decompress(t.path, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rc, tar.NewReader(rc), nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// dirIndex maps a directory to it's entries.
+// The root directory is the empty string, others end with a slash.
+// Entries which are directories end with a slash.
+type dirIndex map[string][]string
+
+// add adds a file or directory and all of it's parents.
+func (d dirIndex) add(name string) {
+	name = strings.TrimPrefix(name, "./")
+	if name == "" || name == "/" {
+		return
+	}
+	dir, base := path.Split(strings.TrimSuffix(name, "/"))
+	if strings.HasSuffix(name, "/") {
+		base += "/"
+		if _, ok := d[name]; ok == false {
+			d[name] = nil
+		}
+	}
+	for _, s := range d[dir] {
+		if s == base {
+			return
+		}
+	}
+	d[dir] = append(d[dir], base)
+	sort.Strings(d[dir])
+	if dir != "" {
+		d.add(dir)
+	}
+}
+
+// list returns the directory listing in the same format as an os directory.
+func (d dirIndex) list(name, mpt string) (io.ReadCloser, bool) {
+	entries, ok := d[name]
+	if ok == false && name != "" {
+		return nil, false
+	}
+	names := make([]string, len(entries))
+	for i, s := range entries {
+		names[i] = mpt + name + s
+	}
+	return ioutil.NopCloser(strings.NewReader(strings.Join(names, "\n"))), true
+}
+
+func notFound(name string) error {
+	return &os.PathError{
+		Op:   "open",
+		Path: name,
+		Err:  fmt.Errorf("file does not exist"),
+	}
+}
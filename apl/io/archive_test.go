@@ -0,0 +1,123 @@
+package io
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ktye/iv/apl"
+)
+
+func TestArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"a.txt":     "alpha\n",
+		"sub/b.txt": "beta\ngamma\n",
+	}
+	writeZip(t, filepath.Join(dir, "x.zip"), files)
+	writeTgz(t, filepath.Join(dir, "x.tar.gz"), files)
+	writeGz(t, filepath.Join(dir, "c.log.gz"), "compressed\n")
+
+	a := newApl()
+	if _, err := cd(a, nil, apl.String(dir)); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"/z/" io→mount "zip://x.zip"`, `"/t/" io→mount "tar://x.tar.gz"`} {
+		if err := a.ParseAndEval(s); err != nil {
+			t.Fatalf("%s: %s", s, err)
+		}
+	}
+
+	testCases := []struct {
+		in, exp string
+	}{
+		{"/z/", "/z/a.txt\n/z/sub/"},
+		{"/z/sub/", "/z/sub/b.txt"},
+		{"/z/sub/b.txt", "beta\ngamma"},
+		{"/t/", "/t/a.txt\n/t/sub/"},
+		{"/t/sub/b.txt", "beta\ngamma"},
+		{"/c.log.gz", "compressed"},
+	}
+	for _, tc := range testCases {
+		c, err := read(a, nil, apl.String(tc.in))
+		if err != nil {
+			t.Fatalf("%s: %s", tc.in, err)
+		}
+		var lines []string
+		for v := range c.(apl.Channel)[0] {
+			lines = append(lines, v.String(a.Format))
+		}
+		if got := strings.Join(lines, "\n"); got != tc.exp {
+			t.Fatalf("%s: expected %q got %q", tc.in, tc.exp, got)
+		}
+	}
+
+	if _, err := Create(a, "/z/new"); err == nil {
+		t.Fatal("zip file system must be readonly")
+	}
+	Umount(a, "/z/")
+}
+
+func writeZip(t *testing.T, name string, files map[string]string) {
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for n, s := range files {
+		fw, err := w.Create(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(s))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTgz(t *testing.T, name string, files map[string]string) {
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	z := gzip.NewWriter(f)
+	w := tar.NewWriter(z)
+	for n, s := range files {
+		if err := w.WriteHeader(&tar.Header{Name: n, Mode: 0644, Size: int64(len(s))}); err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(s))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeGz(t *testing.T, name string, s string) {
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	z := gzip.NewWriter(f)
+	z.Write([]byte(s))
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
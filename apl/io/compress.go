@@ -0,0 +1,52 @@
+package io
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strings"
+)
+
+// decompress returns a reader that decompresses rc, depending on the file extension.
+// Known extensions are .gz, .tgz, .bz2, .tbz2, .zlib and .zz.
+// Other files are returned unchanged.
+// Closing the returned reader also closes rc.
+func decompress(name string, rc io.ReadCloser) (io.ReadCloser, error) {
+	var r io.Reader
+	var err error
+	switch ext(name) {
+	case ".gz", ".tgz":
+		r, err = gzip.NewReader(rc)
+	case ".bz2", ".tbz2":
+		r = bzip2.NewReader(rc)
+	case ".zlib", ".zz":
+		r, err = zlib.NewReader(rc)
+	default:
+		return rc, nil
+	}
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return decompressor{r, rc}, nil
+}
+
+func ext(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 && strings.IndexByte(name[i:], '/') < 0 {
+		return strings.ToLower(name[i:])
+	}
+	return ""
+}
+
+type decompressor struct {
+	io.Reader
+	rc io.ReadCloser
+}
+
+func (d decompressor) Close() error {
+	if c, ok := d.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return d.rc.Close()
+}
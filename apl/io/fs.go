@@ -8,18 +8,34 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/ktye/iv/apl"
 )
 
 // RegisterProtocol registers a file system protocol, such as "zip".
 // Protocols are recognized by mount with the syntax: "zip://".
 // They are registered by external packages supporting special file systems.
+// They are shared by all interpreters and should be registered from an init function.
 func RegisterProtocol(name string, p Protocol) {
+	protomu.Lock()
+	defer protomu.Unlock()
 	if protocols == nil {
 		protocols = make(map[string]Protocol)
 	}
 	protocols[name] = p
 }
 
+// protocol returns the protocol registered for the interpreter or the global one.
+func (m *mtab) protocol(name string) (Protocol, bool) {
+	if p, ok := m.protocols[name]; ok {
+		return p, true
+	}
+	protomu.Lock()
+	defer protomu.Unlock()
+	p, ok := protocols[name]
+	return p, ok
+}
+
 // FileSystem is the interface for a file system provider.
 // A directory returns the names of it's content in the reader, with directories ending in a slash.
 type FileSystem interface {
@@ -72,15 +88,27 @@ func (o fs) Open(name, mpt string) (io.ReadCloser, error) {
 	return ioutil.NopCloser(strings.NewReader(strings.Join(names, "\n"))), nil
 }
 
+// Write creates or truncates a file.
+func (o fs) Write(name string) (io.WriteCloser, error) {
+	if name == "" || strings.HasSuffix(name, "/") {
+		return nil, fmt.Errorf("cannot write to a directory")
+	}
+	return os.Create(o.path(name))
+}
+
 func (o fs) path(name string) string {
 	return filepath.Join(string(o), filepath.FromSlash(name))
 }
 
-// Mtab is the mounting table.
-// Currently this is shared over all apl instances. This could be changed.
-var mtab struct {
+// mtab is the mounting table of an interpreter.
+// It is created by Register and stored with the interpreter,
+// such that each interpreter or session has it's own view of the file system.
+type mtab struct {
 	sync.Mutex
-	tab []mpoint
+	tab       []mpoint
+	wd        string              // current os directory
+	protocols map[string]Protocol // protocols bound to the interpreter, such as var
+	mem       map[string]*MemFS   // named memory file systems
 }
 
 // Mpoint defines a mount point.
@@ -89,9 +117,61 @@ type mpoint struct {
 	src FileSystem
 }
 
-// Open opens a file or directory from the filesystem.
-func Open(name string) (io.ReadCloser, error) {
-	if fs, mpt, err := lookup(name); err != nil {
+// table returns the mount table of the interpreter.
+func table(a *apl.Apl) (*mtab, error) {
+	if m, ok := a.State("io").(*mtab); ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("io is not registered")
+}
+
+// getwd returns the current directory of the interpreter.
+func (m *mtab) getwd() string {
+	m.Lock()
+	defer m.Unlock()
+	return m.wd
+}
+
+// abs returns the os path for name relative to the current directory.
+func (m *mtab) abs(name string) string {
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(m.getwd(), name)
+}
+
+// cwd is the file system of the current directory.
+// It follows the interpreter's current directory when it is changed with cd.
+type cwd struct {
+	m *mtab
+}
+
+func (c cwd) String() string {
+	return "."
+}
+
+func (c cwd) Open(name, mpt string) (io.ReadCloser, error) {
+	return fs(c.m.getwd()).Open(name, mpt)
+}
+
+func (c cwd) Write(name string) (io.WriteCloser, error) {
+	return fs(c.m.getwd()).Write(name)
+}
+
+// ospath returns the os path of a file, if the file system is an os directory.
+func ospath(fsys FileSystem, relpath string) (string, bool) {
+	switch f := fsys.(type) {
+	case fs:
+		return f.path(relpath), true
+	case cwd:
+		return fs(f.m.getwd()).path(relpath), true
+	}
+	return "", false
+}
+
+// Open opens a file or directory from the file system of the interpreter.
+func Open(a *apl.Apl, name string) (io.ReadCloser, error) {
+	if fs, mpt, err := lookup(a, name); err != nil {
 		return nil, err
 	} else {
 		relpath := strings.TrimPrefix(name, mpt)
@@ -99,28 +179,10 @@ func Open(name string) (io.ReadCloser, error) {
 	}
 }
 
-// Create opens a file for writing from the filesystem.
-func Create(name string) (io.WriteCloser, error) {
-	mtab.Lock()
-	defer mtab.Unlock()
-	n := len(mtab.tab)
-	if n == 0 {
-		return nil, fmt.Errorf("mtab is empty")
-	}
-
-	var fsys FileSystem
-	var relpath string
-	var mpt string
-	for i := n - 1; i >= 0; i-- {
-		t := mtab.tab[i]
-		if strings.HasPrefix(name, t.mpt) {
-			mpt = t.mpt
-			fsys = t.src
-			relpath = strings.TrimPrefix(name, t.mpt)
-			break
-		}
-	}
-	if fsys == nil {
+// Create opens a file for writing from the file system of the interpreter.
+func Create(a *apl.Apl, name string) (io.WriteCloser, error) {
+	fsys, mpt, err := lookup(a, name)
+	if err != nil {
 		return nil, &os.PathError{
 			Op:   "create",
 			Path: name,
@@ -135,13 +197,17 @@ func Create(name string) (io.WriteCloser, error) {
 			Err:  fmt.Errorf("filesystem is readonly: %s", mpt),
 		}
 	}
-	return wfs.Write(relpath)
+	return wfs.Write(strings.TrimPrefix(name, mpt))
 }
 
-func lookup(name string) (FileSystem, string, error) {
-	mtab.Lock()
-	defer mtab.Unlock()
-	n := len(mtab.tab)
+func lookup(a *apl.Apl, name string) (FileSystem, string, error) {
+	m, err := table(a)
+	if err != nil {
+		return nil, "", err
+	}
+	m.Lock()
+	defer m.Unlock()
+	n := len(m.tab)
 	if n == 0 {
 		return nil, "", fmt.Errorf("mtab is empty")
 	}
@@ -149,7 +215,7 @@ func lookup(name string) (FileSystem, string, error) {
 	// Files may shadow each other.
 	// The last mounted file system is tested first.
 	for i := n - 1; i >= 0; i-- {
-		t := mtab.tab[i]
+		t := m.tab[i]
 		if strings.HasPrefix(name, t.mpt) {
 			return t.src, t.mpt, nil
 		}
@@ -161,10 +227,14 @@ func lookup(name string) (FileSystem, string, error) {
 	}
 }
 
-// Mount adds a FileSystem to mtab under the given name.
-func Mount(mpt string, fs FileSystem) error {
-	mtab.Lock()
-	defer mtab.Unlock()
+// Mount adds a FileSystem to the mount table of the interpreter under the given name.
+func Mount(a *apl.Apl, mpt string, fs FileSystem) error {
+	m, err := table(a)
+	if err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
 
 	if strings.HasPrefix(mpt, "/") == false {
 		return fmt.Errorf("io mount: mount point must start with /: %s", mpt)
@@ -173,22 +243,27 @@ func Mount(mpt string, fs FileSystem) error {
 		return fmt.Errorf("io mount: mount point must end with a /: %s", mpt)
 	}
 
-	for _, t := range mtab.tab {
+	for _, t := range m.tab {
 		if t.mpt == mpt {
 			return fmt.Errorf("mount point already used: %s", mpt)
 		}
 	}
-	mtab.tab = append(mtab.tab, mpoint{mpt, fs})
+	m.tab = append(m.tab, mpoint{mpt, fs})
 	return nil
 }
 
-// Umount removes the moint point.
-func Umount(mpt string) {
-	mtab.Lock()
-	defer mtab.Unlock()
+// Umount removes the moint point from the mount table of the interpreter.
+// If the file system implements io.Closer, it is closed.
+func Umount(a *apl.Apl, mpt string) {
+	m, err := table(a)
+	if err != nil {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
 
 	n := -1
-	for i, t := range mtab.tab {
+	for i, t := range m.tab {
 		if t.mpt == mpt {
 			n = i
 		}
@@ -196,10 +271,18 @@ func Umount(mpt string) {
 	if n < 0 {
 		return
 	}
-	mtab.tab = append(mtab.tab[:n], mtab.tab[n+1:]...)
+	if c, ok := m.tab[n].src.(io.Closer); ok {
+		c.Close()
+	}
+	m.tab = append(m.tab[:n], m.tab[n+1:]...)
 }
 
 var protocols map[string]Protocol
+var protomu sync.Mutex
+
+func init() {
+	RegisterProtocol("env", envfs{})
+}
 
 type Protocol interface {
 	FileSystem(string) (FileSystem, error)
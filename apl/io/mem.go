@@ -0,0 +1,252 @@
+package io
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ktye/iv/apl"
+)
+
+// MemFS is a writable file system in memory.
+// It is mounted with the mem protocol:
+//	/m "mem://" `/m     ⍝ mount a new empty file system
+//	/m "mem://x" `/m    ⍝ mount the file system x, which keeps it's content when it is remounted
+// Directories are created implicitly when a file is written,
+// or explicitly by writing to a name that ends with a slash.
+type MemFS struct {
+	sync.Mutex
+	name  string
+	files map[string][]byte
+	dirs  map[string]bool
+}
+
+// NewMemFS returns an empty in-memory file system.
+// It can be mounted from go with Mount.
+func NewMemFS() *MemFS {
+	return &MemFS{files: make(map[string][]byte), dirs: make(map[string]bool)}
+}
+
+func (m *MemFS) String() string { return "mem://" + m.name }
+
+func (m *MemFS) Open(name, mpt string) (io.ReadCloser, error) {
+	m.Lock()
+	defer m.Unlock()
+	if name == "" || strings.HasSuffix(name, "/") {
+		if name != "" && m.dirs[name] == false {
+			return nil, notFound(name)
+		}
+		names := m.list(name)
+		for i := range names {
+			names[i] = mpt + name + names[i]
+		}
+		return ioutil.NopCloser(strings.NewReader(strings.Join(names, "\n"))), nil
+	}
+	b, ok := m.files[name]
+	if ok == false {
+		if m.dirs[name+"/"] {
+			return nil, fmt.Errorf("%s is a directory", name)
+		}
+		return nil, notFound(name)
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+// list returns the sorted entries of the directory.
+func (m *MemFS) list(dir string) []string {
+	var names []string
+	child := func(s string) {
+		s = strings.TrimPrefix(s, dir)
+		if i := strings.IndexByte(s, '/'); i < 0 || i == len(s)-1 {
+			names = append(names, s)
+		}
+	}
+	for s := range m.files {
+		if strings.HasPrefix(s, dir) {
+			child(s)
+		}
+	}
+	for s := range m.dirs {
+		if strings.HasPrefix(s, dir) && s != dir {
+			child(s)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Write creates or truncates a file.
+// The file is stored when the writer is closed.
+// Writing to a name ending with a slash creates a directory.
+func (m *MemFS) Write(name string) (io.WriteCloser, error) {
+	if name == "" {
+		return nil, fmt.Errorf("cannot write to the root directory")
+	}
+	m.Lock()
+	defer m.Unlock()
+	if strings.HasSuffix(name, "/") {
+		if _, ok := m.files[strings.TrimSuffix(name, "/")]; ok {
+			return nil, fmt.Errorf("%s is a file", name)
+		}
+		m.mkdir(name)
+		return memWriter{m: m}, nil
+	} else if m.dirs[name+"/"] {
+		return nil, fmt.Errorf("%s is a directory", name)
+	}
+	return memWriter{m: m, name: name, Buffer: &bytes.Buffer{}}, nil
+}
+
+// mkdir adds the directory and all of it's parents.
+func (m *MemFS) mkdir(dir string) {
+	for dir != "" && m.dirs[dir] == false {
+		m.dirs[dir] = true
+		i := strings.LastIndexByte(strings.TrimSuffix(dir, "/"), '/')
+		dir = dir[:i+1]
+	}
+}
+
+type memWriter struct {
+	*bytes.Buffer
+	m    *MemFS
+	name string
+}
+
+func (w memWriter) Write(p []byte) (int, error) {
+	if w.Buffer == nil {
+		return 0, fmt.Errorf("cannot write to a directory")
+	}
+	return w.Buffer.Write(p)
+}
+
+func (w memWriter) Close() error {
+	if w.Buffer == nil {
+		return nil
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	if i := strings.LastIndexByte(w.name, '/'); i >= 0 {
+		w.m.mkdir(w.name[:i+1])
+	}
+	w.m.files[w.name] = w.Bytes()
+	return nil
+}
+
+// Snapshot returns a copy of all files.
+// Directories are included as names ending with a slash.
+func (m *MemFS) Snapshot() map[string][]byte {
+	m.Lock()
+	defer m.Unlock()
+	s := make(map[string][]byte)
+	for name, b := range m.files {
+		s[name] = append([]byte{}, b...)
+	}
+	for name := range m.dirs {
+		s[name] = nil
+	}
+	return s
+}
+
+// Restore replaces the content of the file system with a snapshot.
+func (m *MemFS) Restore(s map[string][]byte) {
+	m.Lock()
+	defer m.Unlock()
+	m.files = make(map[string][]byte)
+	m.dirs = make(map[string]bool)
+	for name, b := range s {
+		if strings.HasSuffix(name, "/") {
+			m.mkdir(name)
+		} else {
+			if i := strings.LastIndexByte(name, '/'); i >= 0 {
+				m.mkdir(name[:i+1])
+			}
+			m.files[name] = append([]byte{}, b...)
+		}
+	}
+}
+
+// memProtocol creates or returns named memory file systems of an interpreter.
+type memProtocol struct {
+	m *mtab
+}
+
+func (p memProtocol) FileSystem(root string) (FileSystem, error) {
+	if root == "" {
+		return NewMemFS(), nil
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.m.mem == nil {
+		p.m.mem = make(map[string]*MemFS)
+	}
+	fs, ok := p.m.mem[root]
+	if ok == false {
+		fs = NewMemFS()
+		fs.name = root
+		p.m.mem[root] = fs
+	}
+	return fs, nil
+}
+
+// snapshot returns the content of the memory file system mounted at R as a dictionary.
+// Keys are file names, values their content as strings.
+// Called dyadically, it restores the file system from the dictionary L.
+func snapshot(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	mpt, ok := R.(apl.String)
+	if ok == false {
+		return nil, fmt.Errorf("io snapshot: argument must be a mount point: %T", R)
+	}
+	m, err := table(a)
+	if err != nil {
+		return nil, err
+	}
+	var fs *MemFS
+	m.Lock()
+	for _, t := range m.tab {
+		if t.mpt == string(mpt) {
+			fs, _ = t.src.(*MemFS)
+		}
+	}
+	m.Unlock()
+	if fs == nil {
+		return nil, fmt.Errorf("io snapshot: %s is not a memory file system", mpt)
+	}
+
+	if L != nil {
+		d, ok := L.(apl.Object)
+		if ok == false {
+			return nil, fmt.Errorf("io snapshot: left argument must be a dictionary: %T", L)
+		}
+		s := make(map[string][]byte)
+		for _, k := range d.Keys() {
+			name, ok := k.(apl.String)
+			if ok == false {
+				return nil, fmt.Errorf("io snapshot: keys must be strings: %T", k)
+			}
+			v := d.At(k)
+			if str, ok := v.(apl.String); ok {
+				s[string(name)] = []byte(str)
+			} else {
+				s[string(name)] = []byte(v.String(a.Format))
+			}
+		}
+		fs.Restore(s)
+		return apl.EmptyArray{}, nil
+	}
+
+	s := fs.Snapshot()
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	d := apl.Dict{K: make([]apl.Value, len(names)), M: make(map[apl.Value]apl.Value)}
+	for i, name := range names {
+		d.K[i] = apl.String(name)
+		d.M[apl.String(name)] = apl.String(s[name])
+	}
+	return &d, nil
+}
@@ -0,0 +1,47 @@
+package io
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMemFS(t *testing.T) {
+	a := newApl()
+	m := NewMemFS()
+	m.Restore(map[string][]byte{"a": []byte("alpha\n"), "e/": nil})
+	if err := Mount(a, "/m/", m); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		in, exp string
+	}{
+		{`"/m/d/b"<<"/m/a"`, ""}, // copy into an implicit directory
+		{`<"/m/"`, "/m/a\n/m/d/\n/m/e/"},
+		{`<"/m/d/"`, "/m/d/b"},
+		{`<"/m/d/b"`, "alpha"},
+		{`S←io→snapshot "/m/"⋄#S`, "a d/ d/b e/"},
+		{`"/m/a"<<"/m/d/b"⋄<"/m/a"`, "alpha"},
+		{`("z"#"zeta")io→snapshot "/m/"⋄<"/m/"`, "/m/z"},
+		{`S io→snapshot "/m/"⋄<"/m/"`, "/m/a\n/m/d/\n/m/e/"},
+		{`"/n/" io→mount "mem://x"⋄"/n/f"<<"/m/a"⋄io→umount "/n/"⋄"/n/" io→mount "mem://x"⋄<"/n/f"`, "alpha"},
+		{`"/o/" io→mount "mem://"⋄<"/o/"`, ""},
+	}
+	for _, tc := range testCases {
+		var b strings.Builder
+		a.SetOutput(&b)
+		if err := a.ParseAndEval(tc.in); err != nil {
+			t.Fatalf("%s: %s", tc.in, err)
+		}
+		if got := strings.TrimSpace(b.String()); got != tc.exp {
+			t.Fatalf("%s: expected %q got %q", tc.in, tc.exp, got)
+		}
+	}
+
+	if _, err := m.Write(""); err == nil {
+		t.Fatal("expected error writing to root")
+	}
+	if _, err := Open(a, "/m/missing"); err == nil {
+		t.Fatal("expected file not found")
+	}
+}
@@ -3,7 +3,6 @@ package io
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/ktye/iv/apl"
@@ -17,14 +16,19 @@ import (
 // known file systems.
 // If no protocol can be matched, R is considered to be an os path.
 //
-// The special file "." can be used, which is always the current working directory.
+// The special file "." can be used, which is always the current directory of the interpreter.
+// Relative paths are relative to the current directory.
 func mount(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	m, err := table(a)
+	if err != nil {
+		return nil, err
+	}
 	if L == nil {
-		mtab.Lock()
-		defer mtab.Unlock()
+		m.Lock()
+		defer m.Unlock()
 
 		d := apl.Dict{}
-		for _, t := range mtab.tab {
+		for _, t := range m.tab {
 			name := apl.String(t.mpt)
 			d.K = append(d.K, name)
 			if d.M == nil {
@@ -47,41 +51,37 @@ func mount(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
 	}
 
 	// Test if the filesystem matches a registerd protocol.
-	for name, f := range protocols {
-		pre := name + "://"
-		if strings.HasPrefix(src, pre) {
-			fsys, err := f.FileSystem(strings.TrimPrefix(src, pre))
+	if i := strings.Index(src, "://"); i > 0 {
+		if f, ok := m.protocol(src[:i]); ok {
+			fsys, err := f.FileSystem(src[i+3:])
 			if err != nil {
 				return nil, err
 			}
-			if err := Mount(mpt, fsys); err != nil {
+			if err := Mount(a, mpt, fsys); err != nil {
 				return nil, err
 			}
 			return apl.EmptyArray{}, nil
 		}
 	}
 
-	// Special case, "." remains always relative.
+	// Special case, "." always follows the current directory.
 	if src == "." {
-		if err := Mount(mpt, fs(".")); err != nil {
+		if err := Mount(a, mpt, cwd{m}); err != nil {
 			return nil, err
 		}
 		return apl.EmptyArray{}, nil
 	}
 
 	// Mount a directory.
-	fi, err := os.Stat(src)
+	abs := m.abs(src)
+	fi, err := os.Stat(abs)
 	if err != nil {
 		return nil, err
 	}
 	if fi.IsDir() == false {
 		return nil, fmt.Errorf("io mount: src is not a directory: %s", src)
 	}
-	abs, err := filepath.Abs(src)
-	if err != nil {
-		return nil, err
-	}
-	if err := Mount(mpt, fs(abs)); err != nil {
+	if err := Mount(a, mpt, fs(abs)); err != nil {
 		return nil, err
 	}
 	return apl.EmptyArray{}, nil
@@ -93,38 +93,45 @@ func umount(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
 	if ok == false {
 		return nil, fmt.Errorf("io umount: argument must be a string %T", R)
 	}
-	Umount(string(s))
+	Umount(a, string(s))
 	return apl.EmptyArray{}, nil
 }
 
-// cd changes the working directory.
+// cd changes the current directory of the interpreter.
+// It does not change the working directory of the process.
 // If R is empty, it returns the current directory.
 func cd(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	m, err := table(a)
+	if err != nil {
+		return nil, err
+	}
 	s, ok := R.(apl.String)
 	if ok == false {
-		dir, err := os.Getwd()
-		if err != nil {
-			return nil, err
-		} else {
-			return apl.String(dir), nil
-		}
+		return apl.String(m.getwd()), nil
 	}
-	if err := os.Chdir(string(s)); err != nil {
+	dir := m.abs(string(s))
+	fi, err := os.Stat(dir)
+	if err != nil {
 		return nil, err
+	} else if fi.IsDir() == false {
+		return nil, fmt.Errorf("io cd: not a directory: %s", s)
 	}
+	m.Lock()
+	m.wd = dir
+	m.Unlock()
 	return apl.EmptyArray{}, nil
 }
 
-func mCmd(t []scan.Token) []scan.Token {
+func mCmd(t []scan.Token, _ scan.Options) ([]scan.Token, error) {
 	if len(t) == 0 {
 		// List mtab.
 		return []scan.Token{
 			scan.Token{T: scan.Identifier, S: "io→mount"},
 			scan.Token{T: scan.Number, S: "0"},
-		}
+		}, nil
 	}
 	if len(t) < 2 {
-		return t // one argument is an error.
+		return nil, fmt.Errorf("mount point is missing")
 	}
 
 	// Replace . / of the next two tokens to strings.
@@ -134,19 +141,13 @@ func mCmd(t []scan.Token) []scan.Token {
 		}
 	}
 	tokens := []scan.Token{t[1], scan.Token{T: scan.Identifier, S: "io→mount"}, t[0]}
-	return append(tokens, t[2:]...)
+	return append(tokens, t[2:]...), nil
 }
 
-func cdCmd(t []scan.Token) []scan.Token {
+func cdCmd(t []scan.Token, _ scan.Options) ([]scan.Token, error) {
 	cdt := scan.Token{T: scan.Identifier, S: "io→cd"}
 	if len(t) == 0 {
-		return []scan.Token{cdt, scan.Token{T: scan.Number, S: "0"}}
+		return []scan.Token{cdt, scan.Token{T: scan.Number, S: "0"}}, nil
 	}
-	return append([]scan.Token{cdt}, t...)
-}
-
-type toCommand func([]scan.Token) []scan.Token
-
-func (f toCommand) Rewrite(t []scan.Token) []scan.Token {
-	return f(t)
+	return append([]scan.Token{cdt}, t...), nil
 }
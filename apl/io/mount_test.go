@@ -0,0 +1,79 @@
+package io
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/numbers"
+	"github.com/ktye/iv/apl/operators"
+	"github.com/ktye/iv/apl/primitives"
+)
+
+func newApl() *apl.Apl {
+	var b strings.Builder
+	a := apl.New(&b)
+	numbers.Register(a)
+	primitives.Register(a)
+	operators.Register(a)
+	Register(a, "")
+	return a
+}
+
+// Two interpreters have separate mount tables and current directories.
+func TestMtab(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(dir+"/f", []byte("alpha"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := newApl(), newApl()
+	if _, err := mount(a, apl.String("/x/"), apl.String(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(b, "/x/f"); err == nil {
+		t.Fatal("b: /x/ should not be mounted")
+	}
+	r, err := Open(a, "/x/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := ioutil.ReadAll(r); string(c) != "alpha" {
+		t.Fatalf("a: /x/f: got %q", c)
+	}
+	r.Close()
+
+	// The var protocol is bound to each interpreter.
+	if err := a.ParseAndEval("X←1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(b, "/v/X"); err == nil {
+		t.Fatal("b: /v/X should not exist")
+	}
+
+	// cd changes only the directory of a, the root mount follows it.
+	wd, _ := os.Getwd()
+	if _, err := cd(a, nil, apl.String(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := cd(a, nil, apl.EmptyArray{}); v.(apl.String) != apl.String(dir) {
+		t.Fatalf("a: cd: got %s", v.(apl.String))
+	}
+	if v, _ := cd(b, nil, apl.EmptyArray{}); v.(apl.String) != apl.String(wd) {
+		t.Fatalf("b: cd: got %s", v.(apl.String))
+	}
+	if r, err := Open(a, "/f"); err != nil {
+		t.Fatal(err)
+	} else {
+		r.Close()
+	}
+	if got, _ := os.Getwd(); got != wd {
+		t.Fatalf("process directory changed to %s", got)
+	}
+}
@@ -16,7 +16,8 @@ import (
 // Stdin is exported to be overwritable by tests.
 var Stdin io.ReadCloser = os.Stdin
 
-// read reads from a file
+// read reads from a file.
+// Compressed files are decompressed, depending on the file extension.
 func read(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
 	name, ok := R.(apl.String)
 	if ok == false {
@@ -28,16 +29,20 @@ func read(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
 		}
 		return nil, fmt.Errorf("io read: expect file name %T", R)
 	}
-	f, err := Open(string(name))
+	f, err := Open(a, string(name))
 	if err != nil {
 		return nil, err
 	}
+	if f, err = decompress(string(name), f); err != nil {
+		return nil, err
+	}
 	return apl.LineReader(f), nil // LineReader closes the file.
 }
 
 // exec executes a program and sends the output through a channel.
 // If called dyadically it uses R as an input, that can be a channel or a Value.
 // If the program starts with a slash, it's location is looked up in the file system.
+// It is started in the current directory of the interpreter.
 // TODO: should all arguments starting with a slash be replaced?
 func exec(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
 	r := R
@@ -61,21 +66,26 @@ func exec(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
 		return nil, fmt.Errorf("io exec: argv empty")
 	}
 
+	m, err := table(a)
+	if err != nil {
+		return nil, err
+	}
+
 	// If the command starts with a slash, we may relocate it.
 	if strings.HasPrefix(argv[0], "/") {
-		fsys, mpt, err := lookup(argv[0])
+		fsys, mpt, err := lookup(a, argv[0])
 		if err != nil {
 			return nil, err
 		}
-		if f, ok := fsys.(fs); ok == false {
+		if p, ok := ospath(fsys, strings.TrimPrefix(argv[0], mpt)); ok == false {
 			return nil, fmt.Errorf("exec: %s: file system is not an os fs: %s", argv[0], fsys.String())
 		} else {
-			relpath := strings.TrimPrefix(argv[0], mpt)
-			argv[0] = f.path(relpath)
+			argv[0] = p
 		}
 	}
 
 	cmd := ex.Command(argv[0], argv[1:]...)
+	cmd.Dir = m.getwd()
 	cmd.Stdin = in
 	out, err := cmd.StdoutPipe()
 	if err != nil {
@@ -98,10 +108,13 @@ func load(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
 		return nil, fmt.Errorf("io l: argument must be a file name: %T", R)
 	}
 
-	f, err := Open(string(s))
+	f, err := Open(a, string(s))
 	if err != nil {
 		return nil, err
 	}
+	if f, err = decompress(string(s), f); err != nil {
+		return nil, err
+	}
 	defer f.Close()
 
 	if L == nil {
@@ -122,11 +135,17 @@ func load(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
 	return apl.EmptyArray{}, nil
 }
 
-func lCmd(t []scan.Token) []scan.Token {
-	// /l `/file.apl `pkg
+// lCmd rewrites /l `/file.apl `pkg or /l -p=`pkg `/file.apl
+func lCmd(t []scan.Token, opt scan.Options) ([]scan.Token, error) {
+	l := scan.Token{T: scan.Identifier, S: "io→l"}
+	if p, ok := opt["p"]; ok {
+		if p.T != scan.String || len(t) != 1 {
+			return nil, fmt.Errorf("usage: /l -p=`pkg `file")
+		}
+		return []scan.Token{p, l, t[0]}, nil
+	}
 	if len(t) == 2 && t[0].T == scan.String && t[1].T == scan.String {
-		return []scan.Token{t[1], scan.Token{T: scan.Identifier, S: "io→l"}, t[0]}
+		return []scan.Token{t[1], l, t[0]}, nil
 	}
-	l := scan.Token{T: scan.Identifier, S: "io→l"}
-	return append([]scan.Token{l}, t...)
+	return append([]scan.Token{l}, t...), nil
 }
@@ -5,6 +5,8 @@
 package io
 
 import (
+	"os"
+
 	"github.com/ktye/iv/apl"
 	"github.com/ktye/iv/apl/domain"
 	"github.com/ktye/iv/apl/scan"
@@ -17,20 +19,22 @@ func Register(a *apl.Apl, name string) {
 		name = "io"
 	}
 	pkg := map[string]apl.Value{
-		"cd":     apl.ToFunction(cd),
-		"e":      apl.ToFunction(env),
-		"l":      apl.ToFunction(load),
-		"r":      apl.ToFunction(read),
-		"x":      apl.ToFunction(exec),
-		"mount":  apl.ToFunction(mount),
-		"umount": apl.ToFunction(umount),
-	}
-	cmd := map[string]scan.Command{
-		"cd": toCommand(cdCmd),
-		"l":  toCommand(lCmd),
-		"m":  toCommand(mCmd),
+		"cd":       apl.ToFunction(cd),
+		"cp":       apl.ToFunction(copyFile),
+		"e":        apl.ToFunction(env),
+		"l":        apl.ToFunction(load),
+		"r":        apl.ToFunction(read),
+		"x":        apl.ToFunction(exec),
+		"mount":    apl.ToFunction(mount),
+		"snapshot": apl.ToFunction(snapshot),
+		"umount":   apl.ToFunction(umount),
 	}
-	a.AddCommands(cmd)
+	a.AddCommands(
+		scan.NewCommand("cd", "/cd [DIR]", "show or change the current directory", cdCmd),
+		scan.NewCommand("l", "/l FILE [PKG]", "load an APL file, -p=PKG loads it into a package", lCmd, "p"),
+		scan.NewCommand("m", "/m [SRC MPT]", "list the mount table or mount SRC at MPT", mCmd),
+	)
+	a.AddIdiom("<<", scan.Token{T: scan.Identifier, S: name + "→cp"})
 	a.RegisterPackage(name, pkg)
 
 	a.RegisterPrimitive("<", apl.ToHandler(
@@ -43,6 +47,11 @@ func Register(a *apl.Apl, name string) {
 		domain.Monadic(domain.ToIndex(nil)),
 		"read fd",
 	))
+	a.RegisterPrimitive("<", apl.ToHandler(
+		write,
+		domain.Dyadic(domain.Split(domain.IsString(nil), domain.IsChannel(nil))),
+		"write file",
+	))
 	a.RegisterPrimitive("!", apl.ToHandler(
 		exec,
 		domain.Monadic(domain.ToStringArray(nil)),
@@ -53,8 +62,17 @@ func Register(a *apl.Apl, name string) {
 		domain.Dyadic(domain.Split(domain.ToStringArray(nil), nil)),
 		"exec",
 	))
-	RegisterProtocol("var", varfs{Apl: a})
-	RegisterProtocol("env", envfs{})
+
+	// Each interpreter has it's own mount table and current directory.
+	m := mtab{}
+	m.protocols = map[string]Protocol{
+		"var": varfs{Apl: a},
+		"zip": archive{&m, "zip"},
+		"tar": archive{&m, "tar"},
+		"mem": memProtocol{&m},
+	}
+	m.wd, _ = os.Getwd()
+	a.SetState("io", &m)
 	mount(a, apl.String("/"), apl.String("."))
 	mount(a, apl.String("/v/"), apl.String("var:///"))
 	mount(a, apl.String("/e/"), apl.String("env:///"))
@@ -0,0 +1,85 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/ktye/iv/apl"
+)
+
+// write drains the channel R into the file L.
+// Each value is written on a line in it's string representation.
+// The file system of the mount point must be writable.
+//
+// Combined with read and exec, this gives the idioms:
+//	`dst<<`src     copy a file, also between file systems, see copyFile
+//	`log<!`prog    redirect the output of a program to a file
+func write(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	name, ok := L.(apl.String)
+	if ok == false {
+		return nil, fmt.Errorf("io write: left argument must be a file name: %T", L)
+	}
+	c, ok := R.(apl.Channel)
+	if ok == false {
+		return nil, fmt.Errorf("io write: right argument must be a channel: %T", R)
+	}
+
+	wc, err := Create(a, string(name))
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	w := bufio.NewWriter(wc)
+	for v := range c[0] {
+		if e, ok := v.(apl.Error); ok {
+			err = e.E
+			break
+		}
+		if _, err = fmt.Fprintln(w, v.String(a.Format)); err != nil {
+			break
+		}
+	}
+	c.Close()
+	if e := w.Flush(); err == nil {
+		err = e
+	}
+	if e := wc.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return nil, err
+	}
+	return apl.EmptyArray{}, nil
+}
+
+// copyFile copies the file R to L: L io→cp R
+// The bytes are copied unchanged, compressed files are not decompressed.
+// The scanner replaces the idiom L<<R with a call to copyFile.
+func copyFile(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	dst, ok := L.(apl.String)
+	if ok == false {
+		return nil, fmt.Errorf("io cp: left argument must be a file name: %T", L)
+	}
+	src, ok := R.(apl.String)
+	if ok == false {
+		return nil, fmt.Errorf("io cp: right argument must be a file name: %T", R)
+	}
+	r, err := Open(a, string(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	w, err := Create(a, string(dst))
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(w, r)
+	if e := w.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return nil, err
+	}
+	return apl.EmptyArray{}, nil
+}
@@ -0,0 +1,99 @@
+package io
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	ex "os/exec"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(dir+"/src", []byte("alpha\nbeta\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := newApl()
+	lines := []string{
+		`"/x/" io→mount "` + dir + `"`,
+		`"/x/dst"<<"/x/src"`,
+	}
+	if _, err := ex.LookPath("echo"); err == nil {
+		lines = append(lines, `"/x/log"<!"echo" "gamma"`)
+	}
+	for _, s := range lines {
+		if err := a.ParseAndEval(s); err != nil {
+			t.Fatalf("%s: %s", s, err)
+		}
+	}
+	if b, err := ioutil.ReadFile(dir + "/dst"); err != nil {
+		t.Fatal(err)
+	} else if string(b) != "alpha\nbeta\n" {
+		t.Fatalf("copy: got %q", b)
+	}
+	if len(lines) == 3 {
+		if b, err := ioutil.ReadFile(dir + "/log"); err != nil {
+			t.Fatal(err)
+		} else if string(b) != "gamma\n" {
+			t.Fatalf("redirect: got %q", b)
+		}
+	}
+
+	// Binary files are copied unchanged.
+	bin := make([]byte, 100000)
+	for i := range bin {
+		bin[i] = byte(i * 7)
+	}
+	if err := ioutil.WriteFile(dir+"/bin", bin, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ParseAndEval(`"/x/bin2"<<"/x/bin"`); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(dir + "/bin2"); err != nil {
+		t.Fatal(err)
+	} else if bytes.Equal(b, bin) == false {
+		t.Fatalf("binary copy differs: %d bytes", len(b))
+	}
+
+	// Compressed files are not decompressed by the copy.
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("alpha\n"))
+	zw.Close()
+	if err := ioutil.WriteFile(dir+"/a.gz", gz.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ParseAndEval(`"/x/b.gz"<<"/x/a.gz"`); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(dir + "/b.gz"); err != nil {
+		t.Fatal(err)
+	} else if bytes.Equal(b, gz.Bytes()) == false {
+		t.Fatalf("gz copy differs: %q", b)
+	}
+
+	// Writing a channel writes the remaining lines.
+	if err := a.ParseAndEval(`X←<"/x/src"⋄↑X⋄"/x/rest"<X`); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(dir + "/rest"); err != nil {
+		t.Fatal(err)
+	} else if string(b) != "beta\n" {
+		t.Fatalf("rest: got %q", b)
+	}
+
+	// Write to a variable.
+	if err := a.ParseAndEval(`X←"old"⋄"/v/X"<<"/x/src"`); err != nil {
+		t.Fatal(err)
+	}
+	if v := a.Lookup("X"); v == nil || v.String(a.Format) != "alpha\nbeta\n" {
+		t.Fatalf("var: got %v", v)
+	}
+}
@@ -48,17 +48,19 @@ tail:
 	e.vars["⍺"] = l
 	e.vars["⍵"] = r
 
+	// Errors within the lambda body have positions of it's definition,
+	// they are located at the caller instead.
 	if v, err := λ.body.Eval(a); err != nil {
-		return nil, err
+		return nil, unwrapPos(err)
 	} else if t, ok := v.(*tail); ok {
 		r, err = t.right.Eval(a)
 		if err != nil {
-			return nil, err
+			return nil, unwrapPos(err)
 		}
 		if t.left != nil {
 			l, err = t.left.Eval(a)
 			if err != nil {
-				return nil, err
+				return nil, unwrapPos(err)
 			}
 		}
 		goto tail
@@ -0,0 +1,46 @@
+package linalg
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/ktye/iv/apl"
+)
+
+// chol returns the lower triangular cholesky factor of a hermitian positive definite matrix.
+func chol(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L != nil {
+		return nil, fmt.Errorf("chol: must be called monadically")
+	}
+	A, err := toMatrix(a, R, false)
+	if err != nil {
+		return nil, fmt.Errorf("chol: %s", err)
+	}
+	if A.isHermitian() == false {
+		return nil, fmt.Errorf("chol: matrix is not hermitian")
+	}
+	n := A.n
+	C := newMatrix(n, n)
+	C.real = A.real
+	for j := 0; j < n; j++ {
+		d := real(A.at(j, j))
+		for k := 0; k < j; k++ {
+			c := C.at(j, k)
+			d -= real(c)*real(c) + imag(c)*imag(c)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("chol: matrix is not positive definite")
+		}
+		d = math.Sqrt(d)
+		C.set(j, j, complex(d, 0))
+		for i := j + 1; i < n; i++ {
+			s := A.at(i, j)
+			for k := 0; k < j; k++ {
+				s -= C.at(i, k) * cmplx.Conj(C.at(j, k))
+			}
+			C.set(i, j, s/complex(d, 0))
+		}
+	}
+	return C.value(nil), nil
+}
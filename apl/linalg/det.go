@@ -0,0 +1,134 @@
+package linalg
+
+import (
+	"fmt"
+	"math/cmplx"
+
+	"github.com/ktye/iv/apl"
+)
+
+// det returns the determinant of a square matrix.
+// It is exact for rational arguments.
+func det(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L != nil {
+		return nil, fmt.Errorf("det: must be called monadically")
+	}
+	if Q, ok, err := toRatMatrix(a, R, false); err != nil {
+		return nil, fmt.Errorf("det: %s", err)
+	} else if ok {
+		if Q.m != Q.n {
+			return nil, fmt.Errorf("det: matrix must be square")
+		}
+		return Q.det(), nil
+	}
+
+	A, err := toMatrix(a, R, false)
+	if err != nil {
+		return nil, fmt.Errorf("det: %s", err)
+	}
+	if A.m != A.n {
+		return nil, fmt.Errorf("det: matrix must be square")
+	}
+
+	// Gaussian elimination with partial pivoting.
+	n := A.n
+	X := A.copy()
+	d := complex128(1)
+	for i := 0; i < n; i++ {
+		p := i
+		for k := i + 1; k < n; k++ {
+			if cmplx.Abs(X.at(k, i)) > cmplx.Abs(X.at(p, i)) {
+				p = k
+			}
+		}
+		if X.at(p, i) == 0 {
+			return scalar(0, A.real), nil
+		}
+		if p != i {
+			for j := 0; j < n; j++ {
+				x := X.at(i, j)
+				X.set(i, j, X.at(p, j))
+				X.set(p, j, x)
+			}
+			d = -d
+		}
+		d *= X.at(i, i)
+		for k := i + 1; k < n; k++ {
+			f := X.at(k, i) / X.at(i, i)
+			for j := i + 1; j < n; j++ {
+				X.set(k, j, X.at(k, j)-f*X.at(i, j))
+			}
+		}
+	}
+	return scalar(d, A.real), nil
+}
+
+// lstsq solves R+.×X≡L in the least squares sense for an overdetermined R.
+// L may be a vector or a matrix with the same number of rows as R.
+// It is exact for rational arguments.
+func lstsq(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L == nil {
+		return nil, fmt.Errorf("lstsq: must be called dyadically")
+	}
+	vec := false
+	if ar, ok := L.(apl.Array); ok && len(ar.Shape()) == 1 {
+		vec = true
+	}
+
+	if A, ok, err := toRatMatrix(a, R, false); err != nil {
+		return nil, fmt.Errorf("lstsq: %s", err)
+	} else if ok {
+		if B, ok, err := toRatMatrix(a, L, true); err != nil {
+			return nil, fmt.Errorf("lstsq: %s", err)
+		} else if ok {
+			if A.m != B.m {
+				return nil, fmt.Errorf("lstsq: arguments must have the same number of rows")
+			} else if A.m < A.n {
+				return nil, fmt.Errorf("lstsq: system is underdetermined")
+			}
+			X, err := A.lstsq(B)
+			if err != nil {
+				return nil, fmt.Errorf("lstsq: %s", err)
+			}
+			return X.value(a, vec), nil
+		}
+	}
+
+	A, err := toMatrix(a, R, false)
+	if err != nil {
+		return nil, fmt.Errorf("lstsq: %s", err)
+	}
+	B, err := toMatrix(a, L, true)
+	if err != nil {
+		return nil, fmt.Errorf("lstsq: %s", err)
+	}
+	if A.m != B.m {
+		return nil, fmt.Errorf("lstsq: arguments must have the same number of rows")
+	} else if A.m < A.n {
+		return nil, fmt.Errorf("lstsq: system is underdetermined")
+	}
+
+	// Solve U X = Q* B by back substitution.
+	Q, U := householderQR(A)
+	Y := Q.adjoint().mul(B)
+	n := A.n
+	tol := eps * float64(A.m) * U.norm()
+	X := newMatrix(n, B.n)
+	X.real = A.real && B.real
+	for k := 0; k < B.n; k++ {
+		for i := n - 1; i >= 0; i-- {
+			s := Y.at(i, k)
+			for j := i + 1; j < n; j++ {
+				s -= U.at(i, j) * X.at(j, k)
+			}
+			if cmplx.Abs(U.at(i, i)) <= tol {
+				return nil, fmt.Errorf("lstsq: matrix is rank deficient")
+			}
+			X.set(i, k, s/U.at(i, i))
+		}
+	}
+	if vec {
+		return X.value([]int{n}), nil
+	}
+	return X.value(nil), nil
+}
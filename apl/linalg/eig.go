@@ -0,0 +1,226 @@
+package linalg
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+// eig returns the eigenvalues and eigenvectors of a square matrix as a list (values;vectors;).
+// The eigenvectors are the columns of the second item and are normalized to unit length.
+// For hermitian matrices, eigenvalues are real and sorted in increasing order.
+// Otherwise they may be complex and are returned in the order of the schur decomposition.
+func eig(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L != nil {
+		return nil, fmt.Errorf("eig: must be called monadically")
+	}
+	A, err := toMatrix(a, R, false)
+	if err != nil {
+		return nil, fmt.Errorf("eig: %s", err)
+	}
+	if A.m != A.n {
+		return nil, fmt.Errorf("eig: matrix must be square")
+	}
+	n := A.n
+	hermitian := A.isHermitian()
+
+	T, Z, err := schur(A)
+	if err != nil {
+		return nil, fmt.Errorf("eig: %s", err)
+	}
+
+	if hermitian {
+		// T is diagonal and Z contains the eigenvectors.
+		w := make([]float64, n)
+		for i := range w {
+			w[i] = real(T.at(i, i))
+		}
+		idx := make([]int, n)
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(i, j int) bool { return w[idx[i]] < w[idx[j]] })
+		return apl.List{numbers.FloatArray{Dims: []int{n}, Floats: permute(w, idx)}, permuteColumns(Z, idx).value(nil)}, nil
+	}
+
+	w := newMatrix(1, n)
+	w.real = false
+	for i := 0; i < n; i++ {
+		w.v[i] = T.at(i, i)
+	}
+	V := Z.mul(triangularEigenvectors(T))
+	V.real = false
+	for k := 0; k < n; k++ {
+		norm := 0.0
+		for i := 0; i < n; i++ {
+			norm = hypot(complex(norm, 0), V.at(i, k))
+		}
+		for i := 0; i < n; i++ {
+			V.set(i, k, V.at(i, k)/complex(norm, 0))
+		}
+	}
+	return apl.List{w.value([]int{n}), V.value(nil)}, nil
+}
+
+// hessenberg reduces A to upper hessenberg form H with householder reflections.
+// It returns H and the unitary Q with A = Q H Q*.
+func hessenberg(A *matrix) (*matrix, *matrix) {
+	n := A.n
+	H := A.copy()
+	Q := identity(n)
+	Q.real = A.real
+	for k := 0; k < n-2; k++ {
+		v := householder(H, k+1, k)
+		applyReflectionRight(H, v, k+1)
+		applyReflectionRight(Q, v, k+1)
+	}
+	return H, Q
+}
+
+// schur computes the complex schur decomposition A = Z T Z*
+// with an upper triangular T and unitary Z.
+// It uses the shifted qr algorithm on the hessenberg form.
+func schur(A *matrix) (*matrix, *matrix, error) {
+	n := A.n
+	H, Z := hessenberg(A)
+	norm := H.norm()
+	if norm == 0 {
+		return H, Z, nil
+	}
+
+	c := make([]float64, n)
+	s := make([]complex128, n)
+	hi := n - 1
+	iter := 0
+	for hi > 0 {
+		// Find a negligible subdiagonal element.
+		lo := hi
+		for lo > 0 {
+			d := cmplx.Abs(H.at(lo, lo)) + cmplx.Abs(H.at(lo-1, lo-1))
+			if d == 0 {
+				d = norm
+			}
+			if cmplx.Abs(H.at(lo, lo-1)) <= eps*d {
+				H.set(lo, lo-1, 0)
+				break
+			}
+			lo--
+		}
+		if lo == hi {
+			hi--
+			iter = 0
+			continue
+		}
+		iter++
+		if iter > 30*n {
+			return nil, nil, fmt.Errorf("no convergence")
+		}
+
+		// Wilkinson shift, with exceptional shifts to break cycles.
+		var mu complex128
+		if iter%10 == 0 {
+			mu = H.at(hi, hi) + complex(cmplx.Abs(H.at(hi, hi-1)), 0)
+		} else {
+			mu = wilkinson(H.at(hi-1, hi-1), H.at(hi-1, hi), H.at(hi, hi-1), H.at(hi, hi))
+		}
+
+		// QR step on the active block lo..hi: H-μI = QR, H ← RQ+μI.
+		for k := lo; k <= hi; k++ {
+			H.set(k, k, H.at(k, k)-mu)
+		}
+		for k := lo; k < hi; k++ {
+			c[k], s[k] = givens(H.at(k, k), H.at(k+1, k))
+			for j := k; j < n; j++ {
+				x, y := H.at(k, j), H.at(k+1, j)
+				H.set(k, j, complex(c[k], 0)*x+s[k]*y)
+				H.set(k+1, j, -cmplx.Conj(s[k])*x+complex(c[k], 0)*y)
+			}
+		}
+		for k := lo; k < hi; k++ {
+			rows := k + 2
+			if rows > hi+1 {
+				rows = hi + 1
+			}
+			givensRight(H, k, c[k], s[k], rows)
+			givensRight(Z, k, c[k], s[k], n)
+		}
+		for k := lo; k <= hi; k++ {
+			H.set(k, k, H.at(k, k)+mu)
+		}
+	}
+
+	// Clear the lower triangle.
+	for i := 1; i < n; i++ {
+		for k := 0; k < i; k++ {
+			H.set(i, k, 0)
+		}
+	}
+	return H, Z, nil
+}
+
+// wilkinson returns the eigenvalue of the 2x2 matrix [a b;c d] that is closer to d.
+func wilkinson(a, b, c, d complex128) complex128 {
+	tr := (a + d) / 2
+	r := cmplx.Sqrt((a-d)*(a-d)/4 + b*c)
+	l1, l2 := tr+r, tr-r
+	if cmplx.Abs(l1-d) < cmplx.Abs(l2-d) {
+		return l1
+	}
+	return l2
+}
+
+// givens returns the rotation [c s;-s* c] that maps (x,y) to (r,0).
+func givens(x, y complex128) (float64, complex128) {
+	if y == 0 {
+		return 1, 0
+	}
+	if x == 0 {
+		return 0, cmplx.Conj(y) / complex(cmplx.Abs(y), 0)
+	}
+	r := hypot(x, y)
+	ax := cmplx.Abs(x)
+	alpha := x / complex(ax, 0)
+	return ax / r, alpha * cmplx.Conj(y) / complex(r, 0)
+}
+
+// givensRight multiplies the columns k and k+1 of X from the right
+// with the adjoint of the rotation for the first rows.
+func givensRight(X *matrix, k int, c float64, s complex128, rows int) {
+	cc := complex(c, 0)
+	for i := 0; i < rows; i++ {
+		x, y := X.at(i, k), X.at(i, k+1)
+		X.set(i, k, cc*x+cmplx.Conj(s)*y)
+		X.set(i, k+1, -s*x+cc*y)
+	}
+}
+
+// triangularEigenvectors returns the eigenvectors of the upper triangular matrix T as columns.
+func triangularEigenvectors(T *matrix) *matrix {
+	n := T.n
+	Y := newMatrix(n, n)
+	Y.real = false
+	small := eps * T.norm()
+	if small == 0 {
+		small = math.SmallestNonzeroFloat64
+	}
+	for k := 0; k < n; k++ {
+		lambda := T.at(k, k)
+		Y.set(k, k, 1)
+		for i := k - 1; i >= 0; i-- {
+			var sum complex128
+			for j := i + 1; j <= k; j++ {
+				sum += T.at(i, j) * Y.at(j, k)
+			}
+			d := T.at(i, i) - lambda
+			if cmplx.Abs(d) < small {
+				d = complex(small, 0)
+			}
+			Y.set(i, k, -sum/d)
+		}
+	}
+	return Y
+}
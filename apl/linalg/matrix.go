@@ -0,0 +1,200 @@
+package linalg
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+
+	"github.com/ktye/iv/apl"
+	aplbig "github.com/ktye/iv/apl/big"
+	"github.com/ktye/iv/apl/domain"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+const eps = 2.220446049250313e-16
+
+// matrix is a dense row major complex matrix.
+// Real indicates that the original argument was real.
+type matrix struct {
+	m, n int
+	v    []complex128
+	real bool
+}
+
+func newMatrix(m, n int) *matrix {
+	return &matrix{m: m, n: n, v: make([]complex128, m*n), real: true}
+}
+
+func identity(n int) *matrix {
+	I := newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		I.v[i*n+i] = 1
+	}
+	return I
+}
+
+func (x *matrix) at(i, k int) complex128     { return x.v[i*x.n+k] }
+func (x *matrix) set(i, k int, v complex128) { x.v[i*x.n+k] = v }
+
+func (x *matrix) copy() *matrix {
+	r := matrix{m: x.m, n: x.n, v: make([]complex128, len(x.v)), real: x.real}
+	copy(r.v, x.v)
+	return &r
+}
+
+// adjoint returns the conjugate transpose.
+func (x *matrix) adjoint() *matrix {
+	r := newMatrix(x.n, x.m)
+	r.real = x.real
+	for i := 0; i < x.m; i++ {
+		for k := 0; k < x.n; k++ {
+			r.set(k, i, cmplx.Conj(x.at(i, k)))
+		}
+	}
+	return r
+}
+
+func (x *matrix) mul(y *matrix) *matrix {
+	r := newMatrix(x.m, y.n)
+	r.real = x.real && y.real
+	for i := 0; i < x.m; i++ {
+		for k := 0; k < y.n; k++ {
+			var s complex128
+			for j := 0; j < x.n; j++ {
+				s += x.at(i, j) * y.at(j, k)
+			}
+			r.set(i, k, s)
+		}
+	}
+	return r
+}
+
+// norm returns the largest absolute value of all elements.
+func (x *matrix) norm() float64 {
+	max := 0.0
+	for _, v := range x.v {
+		if a := cmplx.Abs(v); a > max {
+			max = a
+		}
+	}
+	return max
+}
+
+// toMatrix converts R to a complex matrix.
+// A vector is converted to a column matrix, if vec is true.
+func toMatrix(a *apl.Apl, R apl.Value, vec bool) (*matrix, error) {
+	v, ok := domain.ToArray(nil).To(a, R)
+	if ok == false {
+		return nil, fmt.Errorf("argument must be an array: %T", R)
+	}
+	ar := v.(apl.Array)
+	shape := ar.Shape()
+	if vec && len(shape) == 1 {
+		shape = []int{shape[0], 1}
+	} else if len(shape) != 2 {
+		return nil, fmt.Errorf("argument must be a matrix: rank is %d", len(shape))
+	}
+	x := newMatrix(shape[0], shape[1])
+	if f, ok := ar.(numbers.FloatArray); ok {
+		for i, n := range f.Floats {
+			x.v[i] = complex(n, 0)
+		}
+		return x, nil
+	}
+	for i := range x.v {
+		c, isreal, err := toComplex(ar.At(i))
+		if err != nil {
+			return nil, err
+		}
+		x.v[i] = c
+		if isreal == false {
+			x.real = false
+		}
+	}
+	return x, nil
+}
+
+// toComplex converts a numeric scalar to complex128.
+// Precise floats are rounded to float64.
+func toComplex(v apl.Value) (complex128, bool, error) {
+	switch n := v.(type) {
+	case numbers.Float:
+		return complex(float64(n), 0), true, nil
+	case numbers.Complex:
+		return complex128(n), false, nil
+	case apl.Int:
+		return complex(float64(n), 0), true, nil
+	case apl.Bool:
+		if n {
+			return 1, true, nil
+		}
+		return 0, true, nil
+	case aplbig.Float:
+		f, _ := n.Float64()
+		return complex(f, 0), true, nil
+	case aplbig.Int:
+		f, _ := new(big.Rat).SetInt(n.Int).Float64()
+		return complex(f, 0), true, nil
+	case aplbig.Rat:
+		f, _ := n.Float64()
+		return complex(f, 0), true, nil
+	}
+	return 0, false, fmt.Errorf("cannot convert %T to a float or complex number", v)
+}
+
+// value converts the matrix to an apl array.
+// It returns a FloatArray if the matrix is real and all imaginary parts are 0.
+func (x *matrix) value(shape []int) apl.Value {
+	if shape == nil {
+		shape = []int{x.m, x.n}
+	}
+	if x.real || isReal(x.v) {
+		f := numbers.FloatArray{Dims: shape, Floats: make([]float64, len(x.v))}
+		for i, c := range x.v {
+			f.Floats[i] = real(c)
+		}
+		return f
+	}
+	c := numbers.ComplexArray{Dims: shape, Cmplx: make([]complex128, len(x.v))}
+	copy(c.Cmplx, x.v)
+	return c
+}
+
+// scalar converts a complex number to an apl number.
+func scalar(c complex128, isreal bool) apl.Value {
+	if isreal || imag(c) == 0 {
+		return numbers.Float(real(c))
+	}
+	return numbers.Complex(c)
+}
+
+func isReal(v []complex128) bool {
+	for _, c := range v {
+		if imag(c) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isHermitian tests if x is square and equal to it's adjoint within a tolerance.
+func (x *matrix) isHermitian() bool {
+	if x.m != x.n {
+		return false
+	}
+	tol := 8 * eps * x.norm() * float64(x.n)
+	for i := 0; i < x.n; i++ {
+		for k := i; k < x.n; k++ {
+			if cmplx.Abs(x.at(i, k)-cmplx.Conj(x.at(k, i))) > tol {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hypot returns sqrt(|a|²+|b|²) without overflow.
+func hypot(a, b complex128) float64 {
+	return math.Hypot(cmplx.Abs(a), cmplx.Abs(b))
+}
@@ -0,0 +1,122 @@
+package linalg
+
+import (
+	"fmt"
+	"math/cmplx"
+
+	"github.com/ktye/iv/apl"
+)
+
+// qr returns the thin qr decomposition of R as a list (Q;R;).
+// For an m×n matrix with k←m⌊n, Q is m×k with orthonormal columns and R is k×n upper triangular.
+func qr(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L != nil {
+		return nil, fmt.Errorf("qr: must be called monadically")
+	}
+	A, err := toMatrix(a, R, false)
+	if err != nil {
+		return nil, fmt.Errorf("qr: %s", err)
+	}
+	Q, U := householderQR(A)
+	return apl.List{Q.value(nil), U.value(nil)}, nil
+}
+
+// householderQR decomposes A into Q and R using householder reflections.
+func householderQR(A *matrix) (*matrix, *matrix) {
+	m, n := A.m, A.n
+	k := m
+	if n < k {
+		k = n
+	}
+	R := A.copy()
+	vs := make([][]complex128, k)
+	for j := 0; j < k; j++ {
+		vs[j] = householder(R, j, j)
+	}
+
+	// Q is the product of the reflections applied to the first k columns of the identity.
+	Q := newMatrix(m, k)
+	Q.real = A.real
+	for i := 0; i < k; i++ {
+		Q.set(i, i, 1)
+	}
+	for j := k - 1; j >= 0; j-- {
+		applyReflection(Q, vs[j], j, 0, Q.n)
+	}
+
+	U := newMatrix(k, n)
+	U.real = A.real
+	for i := 0; i < k; i++ {
+		for j := i; j < n; j++ {
+			U.set(i, j, R.at(i, j))
+		}
+	}
+	return Q, U
+}
+
+// householder computes the householder vector v that zeros column c of X below row r,
+// and applies it to X from the left.
+// The vector is normalized, such that the reflection is I-2vv*.
+// It returns nil, if the column is already zero.
+func householder(X *matrix, r, c int) []complex128 {
+	v := make([]complex128, X.m-r)
+	norm := 0.0
+	for i := range v {
+		v[i] = X.at(r+i, c)
+		norm = hypot(complex(norm, 0), v[i])
+	}
+	if norm == 0 {
+		return nil
+	}
+	alpha := complex(-norm, 0)
+	if v[0] != 0 {
+		alpha *= v[0] / complex(cmplx.Abs(v[0]), 0)
+	}
+	v[0] -= alpha
+	vn := 0.0
+	for i := range v {
+		vn = hypot(complex(vn, 0), v[i])
+	}
+	if vn == 0 {
+		return nil
+	}
+	for i := range v {
+		v[i] /= complex(vn, 0)
+	}
+	applyReflection(X, v, r, c, X.n)
+	return v
+}
+
+// applyReflection applies I-2vv* to the rows starting at r of X, restricted to the columns c0 to c1.
+func applyReflection(X *matrix, v []complex128, r, c0, c1 int) {
+	if v == nil {
+		return
+	}
+	for j := c0; j < c1; j++ {
+		var s complex128
+		for i := range v {
+			s += cmplx.Conj(v[i]) * X.at(r+i, j)
+		}
+		s *= 2
+		for i := range v {
+			X.set(r+i, j, X.at(r+i, j)-s*v[i])
+		}
+	}
+}
+
+// applyReflectionRight applies I-2vv* from the right to the columns starting at c of X.
+func applyReflectionRight(X *matrix, v []complex128, c int) {
+	if v == nil {
+		return
+	}
+	for i := 0; i < X.m; i++ {
+		var s complex128
+		for j := range v {
+			s += X.at(i, c+j) * v[j]
+		}
+		s *= 2
+		for j := range v {
+			X.set(i, c+j, X.at(i, c+j)-s*cmplx.Conj(v[j]))
+		}
+	}
+}
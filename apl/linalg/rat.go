@@ -0,0 +1,169 @@
+package linalg
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ktye/iv/apl"
+	aplbig "github.com/ktye/iv/apl/big"
+)
+
+// ratMatrix is a dense row major matrix of rational numbers used for exact computations.
+type ratMatrix struct {
+	m, n int
+	v    []*big.Rat
+}
+
+func newRatMatrix(m, n int) *ratMatrix {
+	r := ratMatrix{m: m, n: n, v: make([]*big.Rat, m*n)}
+	for i := range r.v {
+		r.v[i] = new(big.Rat)
+	}
+	return &r
+}
+
+func (x *ratMatrix) at(i, k int) *big.Rat { return x.v[i*x.n+k] }
+
+// toRatMatrix converts R to a rational matrix, if the interpreter uses the big tower
+// and all elements are integers or rationals.
+// It returns false if R contains other values.
+func toRatMatrix(a *apl.Apl, R apl.Value, vec bool) (*ratMatrix, bool, error) {
+	if _, ok := a.Tower.Numbers[reflect.TypeOf(aplbig.Rat{})]; ok == false {
+		return nil, false, nil
+	}
+	ar, ok := R.(apl.Array)
+	if ok == false || ar.Size() == 0 {
+		return nil, false, nil
+	}
+	v := make([]*big.Rat, ar.Size())
+	for i := range v {
+		switch n := ar.At(i).(type) {
+		case apl.Int:
+			v[i] = big.NewRat(int64(n), 1)
+		case apl.Bool:
+			v[i] = new(big.Rat)
+			if n {
+				v[i].SetInt64(1)
+			}
+		case aplbig.Int:
+			v[i] = new(big.Rat).SetInt(n.Int)
+		case aplbig.Rat:
+			v[i] = new(big.Rat).Set(n.Rat)
+		default:
+			return nil, false, nil
+		}
+	}
+	shape := ar.Shape()
+	if vec && len(shape) == 1 {
+		shape = []int{shape[0], 1}
+	} else if len(shape) != 2 {
+		return nil, true, fmt.Errorf("argument must be a matrix: rank is %d", len(shape))
+	}
+	return &ratMatrix{m: shape[0], n: shape[1], v: v}, true, nil
+}
+
+// value converts the matrix to an apl array of big.Rat values.
+func (x *ratMatrix) value(a *apl.Apl, vec bool) apl.Value {
+	shape := []int{x.m, x.n}
+	if vec {
+		shape = []int{x.m * x.n}
+	}
+	res := apl.NewMixed(shape)
+	for i, r := range x.v {
+		res.Values[i] = aplbig.Rat{Rat: r}
+	}
+	return a.UnifyArray(res)
+}
+
+// det computes the determinant by exact gaussian elimination.
+func (x *ratMatrix) det() apl.Value {
+	n := x.n
+	X := newRatMatrix(n, n)
+	for i := range X.v {
+		X.v[i].Set(x.v[i])
+	}
+	d := big.NewRat(1, 1)
+	t := new(big.Rat)
+	for i := 0; i < n; i++ {
+		p := i
+		for p < n && X.at(p, i).Sign() == 0 {
+			p++
+		}
+		if p == n {
+			return aplbig.Rat{Rat: new(big.Rat)}
+		}
+		if p != i {
+			for j := 0; j < n; j++ {
+				X.v[i*n+j], X.v[p*n+j] = X.v[p*n+j], X.v[i*n+j]
+			}
+			d.Neg(d)
+		}
+		d.Mul(d, X.at(i, i))
+		for k := i + 1; k < n; k++ {
+			f := new(big.Rat).Quo(X.at(k, i), X.at(i, i))
+			for j := i + 1; j < n; j++ {
+				X.at(k, j).Sub(X.at(k, j), t.Mul(f, X.at(i, j)))
+			}
+		}
+	}
+	return aplbig.Rat{Rat: d}
+}
+
+// lstsq solves the normal equations A'A X = A'B exactly with gauss-jordan elimination.
+func (A *ratMatrix) lstsq(B *ratMatrix) (*ratMatrix, error) {
+	n, k := A.n, B.n
+	t := new(big.Rat)
+
+	// Augmented system [A'A | A'B].
+	N := newRatMatrix(n, n+k)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n+k; j++ {
+			s := N.at(i, j)
+			for r := 0; r < A.m; r++ {
+				if j < n {
+					s.Add(s, t.Mul(A.at(r, i), A.at(r, j)))
+				} else {
+					s.Add(s, t.Mul(A.at(r, i), B.at(r, j-n)))
+				}
+			}
+		}
+	}
+
+	w := n + k
+	for i := 0; i < n; i++ {
+		p := i
+		for p < n && N.at(p, i).Sign() == 0 {
+			p++
+		}
+		if p == n {
+			return nil, fmt.Errorf("matrix is rank deficient")
+		}
+		if p != i {
+			for j := 0; j < w; j++ {
+				N.v[i*w+j], N.v[p*w+j] = N.v[p*w+j], N.v[i*w+j]
+			}
+		}
+		pivot := new(big.Rat).Set(N.at(i, i))
+		for j := i; j < w; j++ {
+			N.at(i, j).Quo(N.at(i, j), pivot)
+		}
+		for r := 0; r < n; r++ {
+			if r == i || N.at(r, i).Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Set(N.at(r, i))
+			for j := i; j < w; j++ {
+				N.at(r, j).Sub(N.at(r, j), t.Mul(f, N.at(i, j)))
+			}
+		}
+	}
+
+	X := newRatMatrix(n, k)
+	for i := 0; i < n; i++ {
+		for j := 0; j < k; j++ {
+			X.at(i, j).Set(N.at(i, n+j))
+		}
+	}
+	return X, nil
+}
@@ -0,0 +1,33 @@
+// Package linalg provides matrix decompositions and linear algebra functions.
+//
+// Float and complex arrays are computed in complex128 arithmetic.
+// If the argument is real, the result is returned as a FloatArray, if possible.
+// Arrays of big.Int and big.Rat are handled exactly by det and lstsq.
+//
+//	chol R     cholesky decomposition: lower triangular L with R≡L+.×+⍉L
+//	det R      determinant
+//	eig R      eigenvalues and eigenvectors: (values;vectors;)
+//	L lstsq R  least squares solution of R+.×X≡L
+//	qr R       qr decomposition: (Q;R;)
+//	svd R      singular value decomposition: (U;S;V;) with R≡U+.×(S×I)+.×+⍉V
+package linalg
+
+import (
+	"github.com/ktye/iv/apl"
+)
+
+// Register adds the linalg package to the interpreter.
+func Register(a *apl.Apl, name string) {
+	if name == "" {
+		name = "la"
+	}
+	pkg := map[string]apl.Value{
+		"chol":  apl.ToFunction(chol),
+		"det":   apl.ToFunction(det),
+		"eig":   apl.ToFunction(eig),
+		"lstsq": apl.ToFunction(lstsq),
+		"qr":    apl.ToFunction(qr),
+		"svd":   apl.ToFunction(svd),
+	}
+	a.RegisterPackage(name, pkg)
+}
@@ -0,0 +1,132 @@
+package linalg
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+// svd returns the thin singular value decomposition as a list (U;S;V;).
+// S is a vector of singular values in decreasing order.
+// For an m×n matrix with k←m⌊n, U is m×k and V is n×k.
+func svd(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L != nil {
+		return nil, fmt.Errorf("svd: must be called monadically")
+	}
+	A, err := toMatrix(a, R, false)
+	if err != nil {
+		return nil, fmt.Errorf("svd: %s", err)
+	}
+	U, S, V, err := jacobiSVD(A)
+	if err != nil {
+		return nil, fmt.Errorf("svd: %s", err)
+	}
+	return apl.List{U.value(nil), numbers.FloatArray{Dims: []int{len(S)}, Floats: S}, V.value(nil)}, nil
+}
+
+// jacobiSVD computes the singular value decomposition with one-sided jacobi rotations.
+// The columns of A are orthogonalized by plane rotations which are accumulated in V.
+func jacobiSVD(A *matrix) (*matrix, []float64, *matrix, error) {
+	if A.m < A.n {
+		// A* = U S V*  →  A = V S U*
+		U, S, V, err := jacobiSVD(A.adjoint())
+		return V, S, U, err
+	}
+	m, n := A.m, A.n
+	U := A.copy()
+	V := identity(n)
+	V.real = A.real
+
+	converged := false
+	for sweep := 0; sweep < 60 && converged == false; sweep++ {
+		converged = true
+		for p := 0; p < n-1; p++ {
+			for q := p + 1; q < n; q++ {
+				var alpha, beta float64
+				var gamma complex128
+				for i := 0; i < m; i++ {
+					up, uq := U.at(i, p), U.at(i, q)
+					alpha += real(up)*real(up) + imag(up)*imag(up)
+					beta += real(uq)*real(uq) + imag(uq)*imag(uq)
+					gamma += cmplx.Conj(up) * uq
+				}
+				g := cmplx.Abs(gamma)
+				if g == 0 || g <= eps*math.Sqrt(alpha*beta) {
+					continue
+				}
+				converged = false
+
+				// Rotate the columns p and q·conj(e), which have a real inner product.
+				e := gamma / complex(g, 0)
+				zeta := (beta - alpha) / (2 * g)
+				t := 1 / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				if zeta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(1+t*t)
+				s := c * t
+				rotate(U, p, q, c, s, e)
+				rotate(V, p, q, c, s, e)
+			}
+		}
+	}
+	if converged == false {
+		return nil, nil, nil, fmt.Errorf("no convergence")
+	}
+
+	// Singular values are the column norms of U.
+	S := make([]float64, n)
+	for k := 0; k < n; k++ {
+		norm := 0.0
+		for i := 0; i < m; i++ {
+			norm = hypot(complex(norm, 0), U.at(i, k))
+		}
+		S[k] = norm
+		if norm != 0 {
+			for i := 0; i < m; i++ {
+				U.set(i, k, U.at(i, k)/complex(norm, 0))
+			}
+		}
+	}
+
+	// Sort in decreasing order.
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return S[idx[i]] > S[idx[j]] })
+	return permuteColumns(U, idx), permute(S, idx), permuteColumns(V, idx), nil
+}
+
+// rotate applies the rotation to the columns p and q of X.
+func rotate(X *matrix, p, q int, c, s float64, e complex128) {
+	cc, ss := complex(c, 0), complex(s, 0)
+	for i := 0; i < X.m; i++ {
+		xp, xq := X.at(i, p), X.at(i, q)
+		X.set(i, p, cc*xp-ss*cmplx.Conj(e)*xq)
+		X.set(i, q, ss*e*xp+cc*xq)
+	}
+}
+
+func permuteColumns(X *matrix, idx []int) *matrix {
+	r := newMatrix(X.m, len(idx))
+	r.real = X.real
+	for i := 0; i < X.m; i++ {
+		for k, j := range idx {
+			r.set(i, k, X.at(i, j))
+		}
+	}
+	return r
+}
+
+func permute(f []float64, idx []int) []float64 {
+	r := make([]float64, len(idx))
+	for k, j := range idx {
+		r[k] = f[j]
+	}
+	return r
+}
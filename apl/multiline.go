@@ -30,6 +30,7 @@ type LineBuffer struct {
 	a      *Apl
 	tokens []scan.Token
 	level  int
+	line   int
 }
 
 func NewLineBuffer(a *Apl) *LineBuffer {
@@ -42,14 +43,27 @@ func (b *LineBuffer) Add(line string) (bool, error) {
 	if b.a == nil {
 		return false, fmt.Errorf("linebuffer is not initialized (no APL)")
 	}
+	// Tokens store their line offset within the statement.
+	if len(b.tokens) == 0 {
+		b.line = 0
+	} else {
+		b.line++
+	}
 	tokens, err := b.a.Scan(line)
 	if err != nil {
+		if e, ok := err.(scan.PosError); ok {
+			e.Line = b.line
+			err = e
+		}
 		b.reset()
 		return false, err
 	}
 	if len(tokens) == 0 {
 		return false, nil
 	}
+	for i := range tokens {
+		tokens[i].Line = b.line
+	}
 
 	// Join with diamonds. Ommit the diamond if the last token is LeftBrace
 	// or the next token is a RightBrace.
@@ -71,6 +71,10 @@ func (t Time) String(f apl.Format) string {
 }
 func (t Time) Copy() apl.Value { return t }
 
+// GobEncode and GobDecode encode the underlying time.Time, e.g. for rpc.
+func (t Time) GobEncode() ([]byte, error) { return time.Time(t).GobEncode() }
+func (t *Time) GobDecode(b []byte) error  { return (*time.Time)(t).GobDecode(b) }
+
 func (t Time) ToIndex() (int, bool) {
 	return 0, false
 }
@@ -122,33 +122,43 @@ func inner(a *apl.Apl, l, r apl.Value, f, g apl.Function) (apl.Value, error) {
 	res := apl.NewMixed(shape)
 
 	// Iterate of all elements of the resulting array.
-	ic, idx := apl.NewIdxConverter(shape)
-	lic, lidx := apl.NewIdxConverter(ls)
-	ric, ridx := apl.NewIdxConverter(rs)
+	// They are independent and may be computed in parallel.
+	ic, _ := apl.NewIdxConverter(shape)
+	lic, _ := apl.NewIdxConverter(ls)
+	ric, _ := apl.NewIdxConverter(rs)
 	split := len(ls) - 1
-	for i := range res.Values {
-		ic.Indexes(i, idx)
-
-		// Split the indexes in idx into the original indexes of both arrays.
-		copy(lidx, idx[:split])     // The last index is open.
-		copy(ridx[1:], idx[split:]) // The first index is open.
-		var v apl.Value
-		for k := inner - 1; k >= 0; k-- {
-			lidx[len(lidx)-1] = k
-			ridx[0] = k
-			if u, err := g.Call(a, al.At(lic.Index(lidx)), ar.At(ric.Index(ridx))); err != nil {
-				return nil, err
-			} else if k == inner-1 {
-				v = u
-			} else {
-				if u, err := f.Call(a, u, v); err != nil {
-					return nil, err
-				} else {
+	err := parallel(a, f, g).Do(len(res.Values), len(res.Values)*inner, func(lo, hi int) error {
+		idx := make([]int, len(shape))
+		lidx := make([]int, len(ls))
+		ridx := make([]int, len(rs))
+		for i := lo; i < hi; i++ {
+			ic.Indexes(i, idx)
+
+			// Split the indexes in idx into the original indexes of both arrays.
+			copy(lidx, idx[:split])     // The last index is open.
+			copy(ridx[1:], idx[split:]) // The first index is open.
+			var v apl.Value
+			for k := inner - 1; k >= 0; k-- {
+				lidx[len(lidx)-1] = k
+				ridx[0] = k
+				if u, err := g.Call(a, al.At(lic.Index(lidx)), ar.At(ric.Index(ridx))); err != nil {
+					return err
+				} else if k == inner-1 {
 					v = u
+				} else {
+					if u, err := f.Call(a, u, v); err != nil {
+						return err
+					} else {
+						v = u
+					}
 				}
 			}
+			res.Values[i] = v.Copy()
 		}
-		res.Values[i] = v.Copy()
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return a.UnifyArray(res), nil
 }
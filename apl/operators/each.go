@@ -2,6 +2,8 @@ package operators
 
 import (
 	"fmt"
+	"runtime"
+	"sync"
 
 	"github.com/ktye/iv/apl"
 	. "github.com/ktye/iv/apl/domain"
@@ -25,6 +27,13 @@ func init() {
 func each(a *apl.Apl, LO, RO apl.Value) apl.Function {
 	f := LO.(apl.Function)
 	derived := func(a *apl.Apl, l, r apl.Value) (apl.Value, error) {
+		if ax, ok := r.(apl.Axis); ok {
+			c, ok := ax.R.(apl.Channel)
+			if ok == false {
+				return nil, fmt.Errorf("parallel each: right argument must be a channel")
+			}
+			return parallelEach(a, l, c, ax.A, f)
+		}
 		if l == nil {
 			return each1(a, r, f)
 		}
@@ -89,6 +98,155 @@ func eachChannel(a *apl.Apl, L apl.Value, r apl.Channel, f apl.Function) (apl.Va
 	return r.Apply(a, f, L, false), nil
 }
 
+// parallelEach applies f to the values of channel R with multiple workers: f¨[n] R.
+// The axis is the number of workers, 0 starts one for each cpu.
+// Results are written as soon as they are available.
+// With a second axis value of 1, f¨[n 1] R, they are written in input order.
+//
+// Each worker calls f with its own fork of the interpreter.
+// At most 2×n values are processed ahead of the consumer.
+func parallelEach(a *apl.Apl, L apl.Value, R apl.Channel, axis apl.Value, f apl.Function) (apl.Value, error) {
+	to := ToIndexArray(nil)
+	X, ok := to.To(a, axis)
+	if ok == false {
+		return nil, fmt.Errorf("parallel each: axis is not an index array")
+	}
+	x := X.(apl.IntArray).Ints
+	if len(x) < 1 || len(x) > 2 || x[0] < 0 {
+		return nil, fmt.Errorf("parallel each: axis must be the number of workers and an optional order flag")
+	}
+	n, ordered := x[0], len(x) == 2 && x[1] != 0
+	if n == 0 {
+		n = runtime.NumCPU()
+	}
+	l, lc := L.(apl.Channel)
+
+	type job struct {
+		i    int
+		l, r apl.Value
+	}
+	type result struct {
+		i   int
+		v   apl.Value
+		err error
+	}
+	jobs := make(chan job)
+	results := make(chan result)
+	stop := make(chan struct{})
+	ahead := make(chan struct{}, 2*n)
+
+	// Read input values and number them.
+	go func() {
+		defer close(jobs)
+		for i := 0; ; i++ {
+			select {
+			case ahead <- struct{}{}:
+			case <-stop:
+				return
+			}
+			j := job{i: i, l: L}
+			select {
+			case v, ok := <-R[0]:
+				if ok == false {
+					return
+				}
+				j.r = v
+			case <-stop:
+				return
+			}
+			if lc {
+				select {
+				case v, ok := <-l[0]:
+					if ok == false {
+						return
+					}
+					j.l = v
+				case <-stop:
+					return
+				}
+			}
+			select {
+			case jobs <- j:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for k := 0; k < n; k++ {
+		wg.Add(1)
+		go func(b *apl.Apl) {
+			defer wg.Done()
+			for j := range jobs {
+				v, err := f.Call(b, j.l, j.r)
+				select {
+				case results <- result{j.i, v, err}:
+				case <-stop:
+					return
+				}
+			}
+		}(a.Fork())
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	c := apl.NewChannel()
+	go func() {
+		defer close(c[0])
+		defer func() {
+			close(stop)
+			close(R[1])
+			if lc {
+				close(l[1])
+			}
+		}()
+		// send writes a result and reports if processing continues.
+		send := func(r result) bool {
+			v := r.v
+			if r.err != nil {
+				v = apl.Error{E: r.err}
+			}
+			for {
+				select {
+				case c[0] <- v:
+					<-ahead
+					return r.err == nil
+				case _, ok := <-c[1]:
+					if ok == false {
+						return false
+					}
+				}
+			}
+		}
+		pending := make(map[int]result)
+		next := 0
+		for r := range results {
+			if ordered == false {
+				if send(r) == false {
+					return
+				}
+				continue
+			}
+			pending[r.i] = r
+			for {
+				r, ok := pending[next]
+				if ok == false {
+					break
+				}
+				delete(pending, next)
+				next++
+				if send(r) == false {
+					return
+				}
+			}
+		}
+	}()
+	return c, nil
+}
+
 // ChannelEach sends each value in R over a channel.
 func channelEach(a *apl.Apl, _, _ apl.Value) apl.Function {
 	derived := func(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
@@ -0,0 +1,46 @@
+package operators
+
+import "github.com/ktye/iv/apl"
+
+// parallel returns the settings for parallel execution of the functions.
+// Only primitive scalar functions can be called concurrently,
+// all others are executed sequentially.
+func parallel(a *apl.Apl, f ...apl.Function) apl.Parallel {
+	p := a.Parallel
+	for _, fn := range f {
+		if scalarFunction(fn) == false {
+			p.Workers = 1
+		}
+	}
+	return p
+}
+
+// scalarFunction reports if f is a primitive scalar function.
+// They do not modify the interpreter state.
+func scalarFunction(f apl.Function) bool {
+	p, ok := f.(apl.Primitive)
+	if ok == false {
+		return false
+	}
+	switch p {
+	case "+", "-", "×", "÷", "*", "⍟", "|", "⌊", "⌈", "!", "○":
+		return true
+	case "∧", "^", "∨", "⍲", "⍱", "<", "≤", "=", "≥", ">", "≠":
+		return true
+	}
+	return false
+}
+
+// associative reports if f is a primitive scalar function, that is associative.
+// Reductions over a vector can be split into parts.
+func associative(f apl.Function) bool {
+	p, ok := f.(apl.Primitive)
+	if ok == false {
+		return false
+	}
+	switch p {
+	case "+", "×", "⌊", "⌈", "∧", "^", "∨":
+		return true
+	}
+	return false
+}
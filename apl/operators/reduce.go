@@ -205,35 +205,47 @@ func reduct(a *apl.Apl, f apl.Function, l, r apl.Value, axis int) (apl.Value, er
 		for i := range vec {
 			vec[i] = ar.At(i).Copy()
 		}
+		if p := parallel(a, f); p.Workers > 1 && len(vec) >= p.Threshold && len(vec) > reduceChunk && associative(f) {
+			return reduceChunks(a, vec, f)
+		}
 		v, err := reduce(a, vec, f)
 		return v, err
 	}
 
 	// Create a new array with the given axis removed.
+	// Each result element is reduced independently, they may be computed in parallel.
 	v := apl.NewMixed(dims)
+	ic, _ := apl.NewIdxConverter(shape)
+	tc, _ := apl.NewIdxConverter(dims)
+	err := parallel(a, f).Do(len(v.Values), len(v.Values)*n, func(lo, hi int) error {
+		vec := make([]apl.Value, n)
+		sidx := make([]int, len(shape))
+		tidx := make([]int, len(dims))
+		tc.Indexes(lo, tidx)
+		for k := lo; k < hi; k++ {
+			// Copy target index over the source index,
+			// leaving the reduced axis unset.
+			copy(sidx, tidx[:axis])
+			copy(sidx[axis+1:], tidx[axis:])
+			// Iterate over the reduced axis
+			for i := range vec {
+				sidx[axis] = i
+				// TODO: maybe this could be done more efficiently
+				// e.g. by iteration with a fixed increase.
+				vec[i] = ar.At(ic.Index(sidx)).Copy()
+			}
+			apl.IncArrayIndex(tidx, dims)
 
-	vec := make([]apl.Value, n)
-	ic, sidx := apl.NewIdxConverter(shape)
-	tidx := make([]int, len(dims))
-	for k := range v.Values {
-		// Copy target index over the source index,
-		// leaving the reduced axis unset.
-		copy(sidx, tidx[:axis])
-		copy(sidx[axis+1:], tidx[axis:])
-		// Iterate over the reduced axis
-		for i := range vec {
-			sidx[axis] = i
-			// TODO: maybe this could be done more efficiently
-			// e.g. by iteration with a fixed increase.
-			vec[i] = ar.At(ic.Index(sidx)).Copy()
-		}
-		apl.IncArrayIndex(tidx, dims)
-
-		if res, err := reduce(a, vec, f); err != nil {
-			return nil, fmt.Errorf("cannot reduce: %s", err)
-		} else {
-			v.Values[k] = res
+			if res, err := reduce(a, vec, f); err != nil {
+				return fmt.Errorf("cannot reduce: %s", err)
+			} else {
+				v.Values[k] = res
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return a.UnifyArray(v), nil
 }
@@ -661,6 +673,35 @@ func reduce(a *apl.Apl, vec []apl.Value, d apl.Function) (apl.Value, error) {
 	return v.Copy(), nil
 }
 
+// reduceChunk is the length of the parts of a vector, that are reduced in parallel.
+const reduceChunk = 4096
+
+// reduceChunks reduces a vector with an associative function in parallel.
+// The vector is split into chunks of a fixed size, the results of the chunks are reduced again.
+// With a fixed size, the result does not depend on the number of workers.
+// It is only used for parallel execution, the sequential result is a strict right to left reduction.
+func reduceChunks(a *apl.Apl, vec []apl.Value, f apl.Function) (apl.Value, error) {
+	part := make([]apl.Value, (len(vec)+reduceChunk-1)/reduceChunk)
+	err := parallel(a, f).Do(len(part), len(vec), func(lo, hi int) error {
+		for k := lo; k < hi; k++ {
+			end := (k + 1) * reduceChunk
+			if end > len(vec) {
+				end = len(vec)
+			}
+			v, err := reduce(a, vec[k*reduceChunk:end], f)
+			if err != nil {
+				return err
+			}
+			part[k] = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reduce(a, part, f)
+}
+
 func reduceChannel(a *apl.Apl, L apl.Value, f apl.Function, c apl.Channel) (apl.Value, error) {
 	if L != nil {
 		return nil, fmt.Errorf("TODO: n-wise channel reduction")
@@ -0,0 +1,54 @@
+package apl
+
+import "sync"
+
+// Parallel configures the parallel execution of scalar functions on arrays,
+// reductions along an axis and inner products.
+//
+// The work is split into ranges of independent result elements.
+// Each element is computed as in sequential execution, so results do not depend on the settings.
+// A vector reduced by an associative primitive is split into chunks of a fixed length instead,
+// floating point results may differ slightly from sequential execution.
+type Parallel struct {
+	Workers   int // maximum number of goroutines, 0 or 1 disables parallel execution.
+	Threshold int // minimum number of processed elements for parallel execution.
+}
+
+// DefaultParallel is the initial setting of a new interpreter.
+// Execution is sequential, parallel execution is enabled by setting the number of workers, e.g. with a→c.
+var DefaultParallel = Parallel{
+	Workers:   1,
+	Threshold: 100000,
+}
+
+// Do calls f for consecutive ranges [lo, hi) that cover n result elements.
+// Cost is the number of elements processed for all results, e.g. n times the length of a reduction axis.
+// If it is below the threshold, f is called once for the whole range.
+// Otherwise the ranges are processed concurrently.
+// Do returns the error of the first range that fails.
+func (p Parallel) Do(n, cost int, f func(lo, hi int) error) error {
+	w := p.Workers
+	if w > n {
+		w = n
+	}
+	if w < 2 || cost < p.Threshold {
+		return f(0, n)
+	}
+
+	errs := make([]error, w)
+	var wg sync.WaitGroup
+	for k := 0; k < w; k++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			errs[k] = f(k*n/w, (k+1)*n/w)
+		}(k)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -13,6 +13,7 @@ type parser struct {
 	tokens []scan.Token
 	stack  []item
 	pos    int
+	last   scan.Token // last pulled token
 }
 
 const (
@@ -23,10 +24,12 @@ const (
 )
 
 // Item is an element of the parse stack.
-// It contains a expr with an associated class.
+// It contains a expr with an associated class
+// and the position of it's leftmost token.
 type item struct {
 	e     expr
 	class class
+	pos   position
 }
 type class int
 
@@ -126,6 +129,7 @@ func (p *parser) parseStatement() (item, error) {
 		}
 
 		t := p.pull()
+		at := tokenPos(t)
 		switch t.T {
 		case scan.Endl:
 			if len(p.stack) == 0 {
@@ -141,9 +145,9 @@ func (p *parser) parseStatement() (item, error) {
 		case scan.Symbol:
 
 			if _, ok := p.a.primitives[Primitive(t.S)]; ok {
-				push(item{e: Primitive(t.S), class: verb}, false)
+				push(item{e: Primitive(t.S), class: verb, pos: at}, false)
 			} else if ops, ok := p.a.operators[t.S]; ok {
-				i := item{e: &derived{op: t.S}, class: adverb}
+				i := item{e: &derived{op: t.S}, class: adverb, pos: at}
 				if ops[0].DyadicOp() == true {
 					i.class = conjunction
 				}
@@ -152,53 +156,55 @@ func (p *parser) parseStatement() (item, error) {
 				}
 				push(i, false)
 			} else {
-				return item{}, fmt.Errorf("unknown symbol: %s", t.S)
+				return item{}, at.errorf("unknown symbol: %s", t.S)
 			}
 
 		case scan.Number, scan.String, scan.Chars:
 			e, err := p.collectArray(t)
 			if err != nil {
-				return item{}, err
+				return item{}, at.wrap(err)
 			}
-			push(item{e: e, class: noun}, false)
+			push(item{e: e, class: noun, pos: tokenPos(p.last)}, false)
 
 		case scan.Identifier:
-			i := item{class: verb}
+			i := item{class: verb, pos: at}
 			if ok, fok := isVarname(t.S); ok == false {
-				return item{}, fmt.Errorf("illegal variable name: %s", t.S)
+				return item{}, at.errorf("illegal variable name: %s", t.S)
 			} else if fok == false {
 				e, err := p.collectArray(t)
 				if err != nil {
-					return item{}, err
+					return item{}, at.wrap(err)
 				}
 				i.e = e
 				i.class = noun
+				i.pos = tokenPos(p.last)
 			} else {
 				i.e = fnVar(t.S)
 			}
 			push(i, false)
 
 		case scan.Self:
-			push(item{e: self{}, class: verb}, false)
+			push(item{e: self{}, class: verb, pos: at}, false)
 
 		case scan.LeftParen, scan.LeftBrack, scan.LeftBrace:
-			return item{}, fmt.Errorf("unexpected opening %s", t.S)
+			return item{}, at.errorf("unexpected opening %s", t.S)
 
 		case scan.RightParen, scan.RightBrack, scan.RightBrace:
 			i, err := p.subStatement(t.T)
 			if err != nil {
-				return item{}, fmt.Errorf("%s", err)
+				return item{}, at.wrap(err)
 			}
+			i.pos = tokenPos(p.last)
 			push(i, false)
 
 		case scan.Colon:
-			return item{}, fmt.Errorf("unexpected : outside {}")
+			return item{}, at.errorf("unexpected : outside {}")
 
 		case scan.Semicolon:
-			return item{}, fmt.Errorf("unexpected ; outside []")
+			return item{}, at.errorf("unexpected ; outside []")
 
 		default:
-			return item{}, fmt.Errorf("unknown token %s", t.S)
+			return item{}, at.errorf("unknown token %s", t.S)
 		}
 	}
 	return item{}, fmt.Errorf("illegal parser state") // Should not be reached.
@@ -212,6 +218,7 @@ func (p *parser) pull() scan.Token {
 	}
 	t := p.tokens[len(p.tokens)-1]
 	p.tokens = p.tokens[:len(p.tokens)-1]
+	p.last = t
 	return t
 }
 
@@ -467,7 +474,13 @@ func (p *parser) reduce(last bool) error {
 	p.resolveFunctions(last)
 
 	if last && len(p.stack) > 1 {
-		return fmt.Errorf("cannot reduce expression")
+		// Report the rightmost item, that could not be reduced:
+		// a function without a right argument or the left neighbor of an array.
+		i := p.rightItem(0)
+		if i.class == noun {
+			i = p.rightItem(1)
+		}
+		return i.pos.errorf("cannot reduce expression")
 	}
 	return nil
 }
@@ -488,44 +501,47 @@ func (p *parser) resolveBrackets() error {
 				Function: Primitive("⌷"),
 				left:     spec,
 				right:    id,
+				pos:      p.leftItem(1).pos,
 			}
-			p.setLeft(1, item{e: fn, class: noun})
+			p.setLeft(1, item{e: fn, class: noun, pos: l.pos})
 			p.removeLeft(0)
 			return nil
 		} else if _, ok := l.e.(Primitive); ok {
 			if len(spec) != 1 {
-				return fmt.Errorf("axis must hold a single expression, not %d", len(spec))
+				return l.pos.errorf("axis must hold a single expression, not %d", len(spec))
 			}
 			d := derived{
 				lo: l.e,
 				ro: spec[0],
 				op: "⍂",
 			}
-			p.setLeft(1, item{e: &d, class: verb})
+			p.setLeft(1, item{e: &d, class: verb, pos: l.pos})
 			p.removeLeft(0)
 		} else if _, ok := l.e.(*derived); ok {
 			// The axis specification following an operator is rewritten as a dyadic operator.
 			// The operator is called "⍂" and as the left operand the axis spec is inserted.
 			if len(spec) != 1 {
-				return fmt.Errorf("axis must hold a single expression, not %d", len(spec))
+				return l.pos.errorf("axis must hold a single expression, not %d", len(spec))
 			}
 			d := derived{
 				op: "⍂",
 			}
-			p.setLeft(1, item{e: &d, class: conjunction})
-			p.insertLeft(1, item{e: spec[0], class: noun})
+			at := p.leftItem(1).pos
+			p.setLeft(1, item{e: &d, class: conjunction, pos: at})
+			p.insertLeft(1, item{e: spec[0], class: noun, pos: at})
 		} else if l.class == noun {
 			// Axis specification follows an array or a noun expression.
 			fn := &function{
 				Function: Primitive("⌷"),
 				left:     spec,
 				right:    l.e,
+				pos:      p.leftItem(1).pos,
 			}
-			p.setLeft(1, item{e: fn, class: noun})
+			p.setLeft(1, item{e: fn, class: noun, pos: l.pos})
 			p.removeLeft(0)
 			return nil
 		} else {
-			return fmt.Errorf("bracket expr following an %T %v\n", l.e, l.class)
+			return l.pos.errorf("bracket expr following an %T %v\n", l.e, l.class)
 		}
 	}
 	return nil
@@ -589,7 +605,7 @@ func (p *parser) mopReduce(i int) bool {
 			fmt.Println("dopReduce: overwriting LO")
 		}
 		d.lo = p.leftItem(i).e
-		p.setLeft(i, item{e: d, class: verb})
+		p.setLeft(i, item{e: d, class: verb, pos: p.leftItem(i).pos})
 		p.removeLeft(i + 1)
 		reduced = true
 		i++
@@ -619,7 +635,7 @@ func (p *parser) dopReduce(i int) bool {
 	}
 	d.lo = p.leftItem(i).e
 	d.ro = p.leftItem(i + 2).e
-	p.setLeft(i, item{e: d, class: verb})
+	p.setLeft(i, item{e: d, class: verb, pos: p.leftItem(i).pos})
 	p.removeLeft(i + 1)
 	p.removeLeft(i + 1)
 
@@ -673,8 +689,9 @@ func (p *parser) reducefA(last bool) bool {
 		fn := &function{
 			Function: f.e.(Function),
 			right:    r.e,
+			pos:      f.pos,
 		}
-		p.setRight(1, item{e: fn, class: noun})
+		p.setRight(1, item{e: fn, class: noun, pos: f.pos})
 		p.removeRight(0)
 		return true
 	}
@@ -705,8 +722,9 @@ func (p *parser) reduceAfA(last bool) bool {
 			Function: f.e.(Function),
 			left:     l.e,
 			right:    r.e,
+			pos:      f.pos,
 		}
-		p.setRight(2, item{e: fn, class: noun})
+		p.setRight(2, item{e: fn, class: noun, pos: l.pos})
 		p.removeRight(0)
 		p.removeRight(0)
 	}
@@ -749,7 +767,7 @@ func (p *parser) resolveFunctions(last bool) {
 		r1 := p.rightItem(1)
 		if t, ok := r0.e.(train); ok && r1.class == noun && len(t)%2 == 0 {
 			t = append(train{r1.e}, t...)
-			p.setRight(1, item{e: t, class: verb})
+			p.setRight(1, item{e: t, class: verb, pos: r1.pos})
 			p.removeRight(0)
 		}
 	}
@@ -769,10 +787,10 @@ func (p *parser) reduceff(last bool) bool {
 	if (r0.class == verb && r1.class == verb) && ((len(p.stack) == 2 && last) || c != conjunction) {
 		if t, ok := r0.e.(train); ok {
 			t = append(train{r1.e}, t...)
-			p.setRight(1, item{e: t, class: verb})
+			p.setRight(1, item{e: t, class: verb, pos: r1.pos})
 		} else {
 			t = train{r1.e, r0.e}
-			p.setRight(1, item{e: t, class: verb})
+			p.setRight(1, item{e: t, class: verb, pos: r1.pos})
 		}
 		p.removeRight(0)
 		return true
@@ -790,7 +808,7 @@ func (p *parser) specialJot(i item) item {
 	}
 	dot := p.stack[len(p.stack)-1].e
 	if d, ok := dot.(*derived); ok && d.op == "." {
-		i = item{e: Primitive("∘"), class: verb}
+		i = item{e: Primitive("∘"), class: verb, pos: i.pos}
 	}
 
 	return i
@@ -881,13 +899,16 @@ func (p *parser) linkFuncAssign() {
 	// with the second verb as the right argument.
 	if t, ok := p.stack[0].e.(train); ok && len(t) == 2 {
 		if d, ok := t[0].(*derived); ok && d.op == "←" {
+			at := p.stack[0].pos
 			p.stack = []item{
 				item{
 					e: &function{
 						Function: d,
 						right:    t[1],
+						pos:      at,
 					},
 					class: verb,
+					pos:   at,
 				},
 			}
 		}
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/ktye/iv/apl/scan"
 )
 
 func TestParse(t *testing.T) {
@@ -108,6 +110,41 @@ func TestMultiline(t *testing.T) {
 	}
 }
 
+// TestParseError tests the position of parse errors.
+func TestParseError(t *testing.T) {
+	testCases := []struct {
+		in    string
+		line  int
+		caret string
+	}{
+		{"1+2 ⍤ 3", 0, "    ^"},
+		{"1+ ;", 0, "   ^"},
+		{"1+(2", 0, "  ^"},
+		{"1 2 +", 0, "    ^"},
+		{"3+(1 2 +)+3", 0, "       ^"},
+		{"1+. 2", 0, " ^"},
+		{"X←{\n  1 2 +}", 1, "      ^"},
+		{"X←{\n  1 ⍤}", 1, "    ^"},
+	}
+	for i, tc := range testCases {
+		a := New(os.Stdout)
+		reg(a)
+
+		_, err := a.ParseLines(tc.in)
+		if err == nil {
+			t.Fatalf("[%d] %s: expected error", i+1, tc.in)
+		}
+		e, ok := err.(scan.PosError)
+		if ok == false {
+			t.Fatalf("[%d] %s: error has no position: %s", i+1, tc.in, err)
+		}
+		line := strings.Split(tc.in, "\n")[e.Line]
+		if got := e.Caret(line); e.Line != tc.line || got != line+"\n"+tc.caret {
+			t.Fatalf("[%d] %s: line %d:\n%s", i+1, tc.in, e.Line, got)
+		}
+	}
+}
+
 // For testing the parser we register just a couple of dummy primitives and two operators.
 func reg(a *Apl) {
 	for _, r := range "+-*!>" {
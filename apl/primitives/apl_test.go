@@ -9,8 +9,12 @@ import (
 
 	"github.com/ktye/iv/apl"
 	"github.com/ktye/iv/apl/big"
+	"github.com/ktye/iv/apl/linalg"
 	"github.com/ktye/iv/apl/numbers"
 	"github.com/ktye/iv/apl/operators"
+	aplregexp "github.com/ktye/iv/apl/regexp"
+	"github.com/ktye/iv/apl/signal"
+	"github.com/ktye/iv/apl/stats"
 	aplstrings "github.com/ktye/iv/apl/strings"
 	"github.com/ktye/iv/apl/xgo"
 )
@@ -629,6 +633,15 @@ var testCases = []struct {
 	{`⍴','⊃",a,,b,c"`, "5", 0},
 	{`⍴""⊃" a  b c\tc "`, "4", 0},
 
+	{"⍝ Partitioned enclose, partition", "apl/primitives/partition.go", 0},
+	{"1 0 1 0 0⊂⍳5", "(1 2;3 4 5;)", 0},
+	{"0 1 0 2 0⊂⍳5", "(2 3;;4 5;)", 0},                          // leading items are dropped, empty partitions
+	{"1 0 1⊂[1]3 2⍴⍳6", "( 1 2\n3 4; 5 6;)", 0},                 // partition along the first axis
+	{"1 1 2 2 0 3⊆⍳6", "(1 2;3 4;6;)", 0},                       // new partition where L increases
+	{"1 1 0 1 1 1⊆'ab cde'", "(a b;c d e;)", 0},                 // zeros are dropped
+	{`S←"a" "--" "b" "c" "--" "d"⋄(S≠"--")⊆S`, "(a;b c;d;)", 0}, // split records by markers
+	{`S←"a" "--" "b" "c"⋄(S="--")⊂S`, "(-- b c;)", 0},
+
 	{"⍝ Domino, solve linear system", "apl/primitives/domino.go", 0},
 	{"⌹2 2⍴2 0 0 1", "0.5 0\n0 1", small},
 	// TODO: this fails for big.Float. Remove sfloat and debug
@@ -904,6 +917,57 @@ var testCases = []struct {
 	{`u←s→toupper ⋄ u "alpha"`, "ALPHA", 0},
 	{`";" s→join "alpha" "beta" `, "alpha;beta", 0},
 
+	{"⍝ Linear algebra package", "apl/linalg/register.go", 0},
+	{"la→det 2 2⍴1 2 3 4", "¯2", 0},
+	{"la→det 3 3⍴2 0 1 1 3 2 1 1 2", "6", 0},                                          // exact for rationals
+	{"3 5 7 la→lstsq 3 2⍴1 1 1 2 1 3", "1 2", 0},                                      // least squares
+	{"A←3 3⍴4 1 2 1 3 0 2 0 5⋄C←la→chol A⋄⌊0.5+C+.×⍉C", "4 1 2\n1 3 0\n2 0 5", small}, // cholesky
+	{"Q R←la→qr 3 2⍴1 2 3 4 5 7⋄⌊0.5+Q+.×R⋄⌊0.5+(⍉Q)+.×Q", "1 2\n3 4\n5 7\n1 0\n0 1", small},
+	{"U S V←la→svd 2 3⍴1 2 3 4 5 6⋄S", "9.50803 0.77287", small},
+	{"W V←la→eig 3 3⍴4 1 2 1 3 0 2 0 5⋄W", "1.8549 3.47602 6.66908", small}, // symmetric
+	{"W V←la→eig 2 2⍴0 1 ¯1 0⋄W", "0J1 0J¯1", small},                        // complex eigenvalues
+
+	{"⍝ Signal processing package", "apl/signal/register.go", 0},
+	{"sig→fft 1 2 3 4", "10J0 ¯2J2 ¯2J0 ¯2J¯2", small},
+	{"sig→fft 1 2 3", "6J0 ¯1.5J0.866025 ¯1.5J¯0.866025", small}, // length is not a power of 2
+	{"|sig→ifft sig→fft 1 2 3 4 5 6 7 8", "1 2 3 4 5 6 7 8", small},
+	{"1 sig→fft 2 3⍴1 2 3 4 5 6", "5J0 7J0 9J0\n¯3J0 ¯3J0 ¯3J0", small}, // along the first axis
+	{"C←go→source 2⋄sig→fft¨C", "0J0\n1J0", small},                      // spectrum per record
+	{"1 1 sig→conv 1 2 3", "1 3 5 3", small},
+	{`"hann" sig→window 5`, "0 0.5 1 0.5 0", small},
+	{"(1;1 ¯0.5;) sig→filter 1 0 0 0", "1 0.5 0.25 0.125", small}, // iir filter
+
+	{"⍝ Statistics package", "apl/stats/register.go", 0},
+	{"st→mean 3 2⍴1 2 3 4 5 6", "3 4", small}, // column means
+	{"st→var 1 2 3 4", "1.66667", small},
+	{"0 st→var 1 2 3 4", "1.25", small}, // population variance
+	{"st→std 2 4 4 4 5 5 7 9", "2.13809", small},
+	{"st→median 4 1 2 3", "2.5", small},
+	{"0.25 0.5 st→quantile 1 2 3 4 5", "2 3", small},
+	{"0 1 2 3 st→hist 0.5 1 1.5 2 2.5 3", "1 2 3", small}, // last bin is closed
+	{"2 st→hist 1 2 3 4", "(2 2;1 2.5 4;)", small},
+	{"st→corr 4 2⍴1 4 2 3 3 2 4 1", "1 ¯1\n¯1 1", small},
+	{"st→cov 3 2⍴1 2 2 4 3 6", "1 2\n2 4", small},
+	{"1 2 3 4 st→linreg 3 5 7 9", "1 2", small},
+	{"(4 2⍴1 0 0 1 1 1 2 1) st→linreg 1 3 4 6", "¯0.5 1.5 3.33333", small},
+	{"C←go→source 4⋄st→var C", "1.66667", small}, // online
+	{"C←go→source 4⋄st→median C", "1.5", small},
+	{"C←go→source 5⋄0 2 5 st→hist C", "2 3", small},
+	{"st→median ⍉`a`b#(1 2 3;4 5 9;)", "a b\n2 5", small},
+	{"st→var 1", "fail: not enough samples", small},
+
+	{"⍝ Regular expressions package", "apl/regexp/register.go", 0},
+	{`"a+" rx→match "aa" "bb" "ca"`, "1 0 1", 0},
+	{`"[0-9]+" rx→find "a12b3c456"`, "12 3 456", 0},
+	{`"([a-z])([0-9])" rx→find "a1 b2"`, "(a1 a 1;b2 b 2;)", 0}, // capture groups
+	{`"[0-9]+" rx→find "a1" "b22c3"`, "(1;22 3;)", 0},
+	{`⍴"," rx→split "a,b,,c"`, "4", 0},
+	{`("([a-z])([0-9])";"$2$1";) rx→replace "a1 b2"`, "1a 2b", 0},
+	{`("[0-9]";"#";) rx→replace 2 2⍴"a1" "b" "c3" "dd"`, "a# b\nc# dd", 0},
+	{`P←rx→compile "^b"⋄P rx→match "abc" "bcd"`, "0 1", 0},
+	{`C←go→source 3⋄"1" rx→match⍕¨C`, "0\n1\n0", 0}, // channel
+	{`rx→compile "("`, "fail: missing closing", 0},
+
 	{"⍝ Lists", "apl/list.go", 0},
 	{"(1;2;)", "(1;2;)", 0},
 	{"(1 5 9;(2;3+4;);)", "(1 5 9;(2;7;);)", 0},
@@ -1026,6 +1090,17 @@ var testCases = []struct {
 	{"X←go→t 0⋄X[`V]←'abcd'⋄X[`join]⍨'+'", "(4;a+b+c+d;)", small},
 	{"S←go→s 0⋄#[1]S", "sum", 0},
 	{"T←go→t 0⋄T[`S;`A]←3⋄T[`S;`V]←2 3⋄T[`S]", "A: 3\nB: 0\nV: 2 3", 0},
+	{"X←go→rows 3⋄X[`B]", "0 1 4", 0},                            // slice of structs to table
+	{"go→sums ⍉`A`B#(1 2;3 4;)", "4 6", 0},                       // table to slice of structs
+	{"go→count `a`b`a", "a: 2\nb: 1", 0},                         // map to dict
+	{"go→total `x`y#1.5 2", "3.5", small},                        // dict to map
+	{"2017.03.01 go→after 1h", "2017.03.01T01.00.00.000", small}, // time and duration
+	{"go→deref 5", "(5;1;)", 0},                                  // pointer, uint and bool
+	{"go→apply ({⍵×2};1 2 3;)", "2 4 6", 0},                      // lambda as a go func
+	{"go→sortby (`b`cc`a;{⍺>⍵};)", "cc b a", 0},                  // less function called dyadically
+	{`go→fields ("a,b,,c";{⍵=44};)`, "a b c", 0},                 // strings.FieldsFunc
+	{"go→fields (\"a,b,,c\";{⍵+`a};)", "fail: right argument is not a numeric type", 0}, // failing callback
+	{"go→check ({⍵+`a};1;)", "error: +: right argument is not a numeric type apl.String", 0},
 
 	{"⍝ Channels read, write and close", "apl/primitives/take.go", 0},
 	{"C←go→source 6⋄2 3↑C", "0 1 2\n3 4 5", 0},
@@ -1038,11 +1113,18 @@ var testCases = []struct {
 	{`C←go→source 4⋄5+¨C`, "5\n6\n7\n8", 0},
 	{"C←go→source 3⋄C", "0\n1\n2", 0},
 	{"C←go→source 3⋄-¨C", "0\n¯1\n¯2", 0},
+	{"C←go→source 6⋄{⍵×⍵}¨[3 1]C", "0\n1\n4\n9\n16\n25", 0}, // parallel each, ordered
+	{"C←go→source 6⋄+/{⍵×⍵}¨[0]C", "55", 0},                 // parallel each, one worker per cpu
+	{"C←go→source 4⋄5+¨[2 1]C", "5\n6\n7\n8", 0},
 	{"<¨⍳3", "1\n2\n3", 0},                                 // channel-each
 	{"(<⍤2)2 2 3⍴⍳12", "1 2 3\n4 5 6\n7 8 9\n10 11 12", 0}, // channel-rank
 
 	{"⍝ Communicate over a channel", "apl/channel.go", 0},
 	{`C←go→echo"?"⋄C↓'a'⋄C↓'b'⋄2↑C⋄↓C`, "a\nb\n?a ?b\n1", 0},
+	{"+/go→gen 4", "6", 0},          // go channel to apl channel
+	{"2×¨go→gen 3", "0\n2\n4", 0},   // each over a go channel
+	{"go→sum go→source 5", "10", 0}, // apl channel to go channel
+	{"X←go→adder 0⋄I←X[1]⋄O←X[2]⋄I↓3⋄I↓4⋄↓I⋄↑O", "3\n4\n1\n7", 0}, // send-only go channel
 
 	{"⍝ Primes", "", 0},
 	{"f←{(2=+⌿0=X∘.|X)⌿X←⍳⍵} ⋄ f 42", "2 3 5 7 11 13 17 19 23 29 31 37 41", 0},        // 01-primes
@@ -1121,6 +1203,43 @@ func TestPrecise(t *testing.T) {
 	testApl(t, func(a *apl.Apl) { big.SetPreciseTower(a, 256) }, small)
 }
 
+// TestParallel runs all tests with parallel execution for small arrays.
+func TestParallel(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	testApl(t, func(a *apl.Apl) { a.Parallel = apl.Parallel{Workers: 3, Threshold: 1} }, 0)
+}
+
+// Workers of a parallel each read variables, that are assigned concurrently.
+func TestForkVariables(t *testing.T) {
+	a := apl.New(nil)
+	numbers.Register(a)
+	Register(a)
+	operators.Register(a)
+	xgo.Register(a, "go")
+	if err := a.ParseAndEval("Y←1⋄R←{⍵+Y}¨[4]go→source 2000"); err != nil {
+		t.Fatal(err)
+	}
+	c := a.Lookup("R").(apl.Channel)
+	n := make(chan int)
+	go func() {
+		k := 0
+		for range c[0] {
+			k++
+		}
+		n <- k
+	}()
+	for i := 0; i < 100; i++ {
+		if err := a.ParseAndEval(fmt.Sprintf("Y←%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if k := <-n; k != 2000 {
+		t.Fatalf("expected 2000 values, got %d", k)
+	}
+}
+
 func testApl(t *testing.T, tower func(*apl.Apl), skip int) {
 	log := func(v ...interface{}) {
 		if testing.Short() {
@@ -1184,6 +1303,10 @@ func testApl(t *testing.T, tower func(*apl.Apl), skip int) {
 		operators.Register(a)
 		aplstrings.Register(a, "s")
 		xgo.Register(a, "go")
+		linalg.Register(a, "la")
+		signal.Register(a, "sig")
+		stats.Register(a, "st")
+		aplregexp.Register(a, "rx")
 
 		mustfail := strings.HasPrefix(tc.exp, "fail:")
 		lines := strings.Split(tc.in, "\n")
@@ -115,23 +115,20 @@ func array1(symbol string, fn func(*apl.Apl, apl.Value) (apl.Value, bool)) func(
 	return func(a *apl.Apl, _ apl.Value, R apl.Value) (apl.Value, error) {
 		ar := R.(apl.Array)
 		res := apl.NewMixed(apl.CopyShape(ar))
-		same := true
-		var t reflect.Type
-		for i := range res.Values {
-			val, err := efn(a, nil, ar.At(i))
-			if err != nil {
-				return nil, err
-			}
-			res.Values[i] = val
-			if i == 0 {
-				t = reflect.TypeOf(val)
-			} else if same {
-				if reflect.TypeOf(val) != t {
-					same = false
+		err := a.Parallel.Do(len(res.Values), len(res.Values), func(lo, hi int) error {
+			for i := lo; i < hi; i++ {
+				val, err := efn(a, nil, ar.At(i))
+				if err != nil {
+					return err
 				}
+				res.Values[i] = val
 			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
-		if same {
+		if sameType(res.Values) {
 			u, _ := a.Unify(res, false)
 			return u, nil
 		}
@@ -164,32 +161,28 @@ func array2(symbol string, fn func(*apl.Apl, apl.Value, apl.Value) (apl.Value, b
 			shape = apl.CopyShape(al)
 		}
 		res := apl.NewMixed(shape)
-		same := true
-		var t reflect.Type
-		for i := range res.Values {
-			lv := L
-			if isLarray {
-				lv = al.At(i)
-			}
-			rv := R
-			if isRarray {
-				rv = ar.At(i)
-			}
-			val, err := efn(a, lv, rv)
-			if err != nil {
-				return nil, err
-			} else {
-				res.Values[i] = val
-			}
-			if i == 0 {
-				t = reflect.TypeOf(val)
-			} else if same {
-				if reflect.TypeOf(val) != t {
-					same = false
+		err := a.Parallel.Do(len(res.Values), len(res.Values), func(lo, hi int) error {
+			for i := lo; i < hi; i++ {
+				lv := L
+				if isLarray {
+					lv = al.At(i)
+				}
+				rv := R
+				if isRarray {
+					rv = ar.At(i)
 				}
+				val, err := efn(a, lv, rv)
+				if err != nil {
+					return err
+				}
+				res.Values[i] = val
 			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
-		if same {
+		if sameType(res.Values) {
 			return a.UnifyArray(res), nil
 		}
 		return res, nil
@@ -267,43 +260,50 @@ func arrayAxis(symbol string, fn func(*apl.Apl, apl.Value, apl.Value) (apl.Value
 			rightShape[n] = rs[i]
 		}
 
-		var err error
-		same := true
-		var t reflect.Type
-		var lv, rv, v apl.Value
 		res := apl.NewMixed(apl.CopyShape(al))
-		idx := make([]int, len(res.Dims))
-		ic, rdx := apl.NewIdxConverter(rightShape)
-		for i := range res.Values {
-			copy(rdx, idx)
-			for k := range rdx {
-				if rdx[k] >= rightShape[k] {
-					rdx[k] = 0
+		lc, _ := apl.NewIdxConverter(res.Dims)
+		err := a.Parallel.Do(len(res.Values), len(res.Values), func(lo, hi int) error {
+			idx := make([]int, len(res.Dims))
+			lc.Indexes(lo, idx)
+			ic, rdx := apl.NewIdxConverter(rightShape)
+			for i := lo; i < hi; i++ {
+				copy(rdx, idx)
+				for k := range rdx {
+					if rdx[k] >= rightShape[k] {
+						rdx[k] = 0
+					}
 				}
-			}
-			lv = al.At(i)
-			rv = ar.At(ic.Index(rdx))
-			if flip {
-				lv, rv = rv, lv
-			}
-			v, err = efn(a, lv, rv)
-			if err != nil {
-				return nil, err
-			}
-			res.Values[i] = v
-			if i == 0 {
-				t = reflect.TypeOf(v)
-			} else if same {
-				if reflect.TypeOf(v) != t {
-					same = false
+				lv := al.At(i)
+				rv := ar.At(ic.Index(rdx))
+				if flip {
+					lv, rv = rv, lv
+				}
+				v, err := efn(a, lv, rv)
+				if err != nil {
+					return err
 				}
+				res.Values[i] = v
+				apl.IncArrayIndex(idx, res.Dims)
 			}
-			apl.IncArrayIndex(idx, res.Dims)
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
-		if same {
+		if sameType(res.Values) {
 			u, _ := a.Unify(res, false)
 			return u, nil
 		}
 		return res, nil
 	}
 }
+
+// sameType reports if all values have the same type.
+func sameType(v []apl.Value) bool {
+	for i := 1; i < len(v); i++ {
+		if reflect.TypeOf(v[i]) != reflect.TypeOf(v[0]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,151 @@
+package primitives
+
+import (
+	"fmt"
+
+	"github.com/ktye/iv/apl"
+	. "github.com/ktye/iv/apl/domain"
+)
+
+func init() {
+	register(primitive{
+		symbol: "⊂",
+		doc:    "partitioned enclose",
+		Domain: Dyadic(Split(ToIndexArray(nil), nil)),
+		fn:     partitionedEnclose,
+	})
+	register(primitive{
+		symbol: "⊆",
+		doc:    "partition",
+		Domain: Dyadic(Split(ToIndexArray(nil), nil)),
+		fn:     partition,
+	})
+}
+
+// partitionedEnclose cuts R along the last axis, or the given axis.
+// Each element of L is the number of partitions that start at the corresponding position.
+// Elements before the first partition are dropped.
+// L may be a scalar which is extended to the length of the axis.
+// The result is a List of arrays.
+func partitionedEnclose(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	l, ar, axis, err := partitionArgs(a, L, R)
+	if err != nil {
+		return nil, fmt.Errorf("partitioned enclose: %s", err)
+	}
+
+	var start []int
+	for i, n := range l {
+		for k := 0; k < n; k++ {
+			start = append(start, i)
+		}
+	}
+	res := make(apl.List, len(start))
+	for i := range start {
+		end := len(l)
+		if i < len(start)-1 {
+			end = start[i+1]
+		}
+		res[i] = subAxis(ar, axis, start[i], end)
+	}
+	return res, nil
+}
+
+// partition cuts R along the last axis, or the given axis.
+// A new partition starts whenever an element of L is larger than it's predecessor.
+// Positions where L is 0 are dropped.
+// The result is a List of arrays.
+func partition(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	l, ar, axis, err := partitionArgs(a, L, R)
+	if err != nil {
+		return nil, fmt.Errorf("partition: %s", err)
+	}
+
+	res := apl.List{}
+	start := -1
+	for i := 0; i <= len(l); i++ {
+		n := 0
+		if i < len(l) {
+			n = l[i]
+		}
+		if start >= 0 && (n == 0 || n > l[i-1]) {
+			res = append(res, subAxis(ar, axis, start, i))
+			start = -1
+		}
+		if n > 0 && start < 0 {
+			start = i
+		}
+	}
+	return res, nil
+}
+
+// partitionArgs returns L as a non-negative int slice that conforms to the partition axis of R.
+func partitionArgs(a *apl.Apl, L, R apl.Value) ([]int, apl.Array, int, error) {
+	R, x, err := splitAxis(a, R)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	ar, ok := R.(apl.Array)
+	if ok == false || len(ar.Shape()) == 0 {
+		return nil, nil, 0, fmt.Errorf("right argument must be an array")
+	}
+	shape := ar.Shape()
+	axis := len(shape) - 1
+	if x != nil {
+		if len(x) != 1 {
+			return nil, nil, 0, fmt.Errorf("axis must be a scalar or length 1")
+		}
+		axis = x[0]
+	}
+	if axis < 0 || axis >= len(shape) {
+		return nil, nil, 0, fmt.Errorf("axis out of range: %d (rank %d)", axis, len(shape))
+	}
+
+	n := shape[axis]
+	l := make([]int, n)
+	if _, ok := L.(apl.EmptyArray); ok {
+		if n != 0 {
+			return nil, nil, 0, fmt.Errorf("length error")
+		}
+		return l, ar, axis, nil
+	}
+	ai := L.(apl.IntArray)
+	if len(ai.Dims) != 1 {
+		return nil, nil, 0, fmt.Errorf("left argument must be a scalar or vector")
+	}
+	if len(ai.Ints) == 1 {
+		for i := range l {
+			l[i] = ai.Ints[0]
+		}
+	} else if len(ai.Ints) != n {
+		return nil, nil, 0, fmt.Errorf("length error: %d != %d", len(ai.Ints), n)
+	} else {
+		copy(l, ai.Ints)
+	}
+	for _, i := range l {
+		if i < 0 {
+			return nil, nil, 0, fmt.Errorf("left argument must be non-negative")
+		}
+	}
+	return l, ar, axis, nil
+}
+
+// subAxis returns the part of the array between start and end along the given axis.
+// An empty part is returned as an EmptyArray.
+func subAxis(ar apl.Array, axis, start, end int) apl.Value {
+	if end <= start {
+		return apl.EmptyArray{}
+	}
+	shape := ar.Shape()
+	dims := apl.CopyShape(ar)
+	dims[axis] = end - start
+	res := apl.MakeArray(ar, dims)
+	ic, src := apl.NewIdxConverter(shape)
+	dst := make([]int, len(dims))
+	for i := 0; i < res.Size(); i++ {
+		copy(src, dst)
+		src[axis] += start
+		res.Set(i, ar.At(ic.Index(src)).Copy())
+		apl.IncArrayIndex(dst, dims)
+	}
+	return res
+}
@@ -0,0 +1,178 @@
+package regexp
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/ktye/iv/apl"
+)
+
+// Regexp is a compiled regular expression.
+type Regexp struct {
+	*regexp.Regexp
+}
+
+func (r Regexp) String(f apl.Format) string {
+	return r.Regexp.String()
+}
+
+func (r Regexp) Copy() apl.Value { return r }
+
+// compile returns a Regexp for the pattern R.
+func compile(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L != nil {
+		return nil, fmt.Errorf("compile: must be called monadically")
+	}
+	return pattern(R)
+}
+
+// pattern compiles a string or returns an already compiled Regexp.
+func pattern(v apl.Value) (Regexp, error) {
+	switch p := v.(type) {
+	case Regexp:
+		return p, nil
+	case apl.String:
+		r, err := regexp.Compile(string(p))
+		if err != nil {
+			return Regexp{}, err
+		}
+		return Regexp{r}, nil
+	}
+	return Regexp{}, fmt.Errorf("pattern must be a string or a regexp: %T", v)
+}
+
+// match tests if R contains a match of the pattern L.
+func match(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L == nil {
+		return nil, fmt.Errorf("match: left argument must be a pattern")
+	}
+	p, err := pattern(L)
+	if err != nil {
+		return nil, fmt.Errorf("match: %s", err)
+	}
+	return apply(a, R, "match", func(s string) (apl.Value, error) {
+		return apl.Bool(p.MatchString(s)), nil
+	})
+}
+
+// find returns all matches of the pattern L in R.
+// Without capture groups, the matches are returned as a string array.
+// Otherwise the result is a list with a string array for each match,
+// that contains the full match followed by the groups.
+func find(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L == nil {
+		return nil, fmt.Errorf("find: left argument must be a pattern")
+	}
+	p, err := pattern(L)
+	if err != nil {
+		return nil, fmt.Errorf("find: %s", err)
+	}
+	return apply(a, R, "find", func(s string) (apl.Value, error) {
+		if p.NumSubexp() == 0 {
+			m := p.FindAllString(s, -1)
+			return apl.StringArray{Dims: []int{len(m)}, Strings: m}, nil
+		}
+		all := p.FindAllStringSubmatch(s, -1)
+		l := make(apl.List, len(all))
+		for i, m := range all {
+			l[i] = apl.StringArray{Dims: []int{len(m)}, Strings: m}
+		}
+		return l, nil
+	})
+}
+
+// split slices R into the substrings between the matches of L.
+func split(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L == nil {
+		return nil, fmt.Errorf("split: left argument must be a pattern")
+	}
+	p, err := pattern(L)
+	if err != nil {
+		return nil, fmt.Errorf("split: %s", err)
+	}
+	return apply(a, R, "split", func(s string) (apl.Value, error) {
+		v := p.Split(s, -1)
+		return apl.StringArray{Dims: []int{len(v)}, Strings: v}, nil
+	})
+}
+
+// replace replaces all matches of the pattern with the replacement string.
+// L is a list (pattern;replacement;).
+// The replacement may contain references to groups, see regexp.Expand.
+func replace(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	l, ok := L.(apl.List)
+	if ok == false || len(l) != 2 {
+		return nil, fmt.Errorf("replace: left argument must be a list (pattern;replacement;)")
+	}
+	p, err := pattern(l[0])
+	if err != nil {
+		return nil, fmt.Errorf("replace: %s", err)
+	}
+	repl, ok := l[1].(apl.String)
+	if ok == false {
+		return nil, fmt.Errorf("replace: replacement must be a string")
+	}
+	return apply(a, R, "replace", func(s string) (apl.Value, error) {
+		return apl.String(p.ReplaceAllString(s, string(repl))), nil
+	})
+}
+
+// apply calls f for a string, each string of an array or each value of a channel.
+// Array results are uniform, if f returns a bool or a string, otherwise they are a list.
+func apply(a *apl.Apl, R apl.Value, name string, f func(string) (apl.Value, error)) (apl.Value, error) {
+	call := func(v apl.Value) (apl.Value, error) {
+		s, ok := v.(apl.String)
+		if ok == false {
+			return nil, fmt.Errorf("%s: argument must be a string: %T", name, v)
+		}
+		return f(string(s))
+	}
+	switch r := R.(type) {
+	case apl.String:
+		return call(r)
+	case apl.Channel:
+		fn := func(a *apl.Apl, _, v apl.Value) (apl.Value, error) {
+			return call(v)
+		}
+		return r.Apply(a, apl.ToFunction(fn), nil, false), nil
+	case apl.Array:
+		values := make([]apl.Value, r.Size())
+		for i := range values {
+			v, err := call(r.At(i))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return uniform(apl.CopyShape(r), values), nil
+	}
+	return nil, fmt.Errorf("%s: argument must be a string: %T", name, R)
+}
+
+// uniform returns a BoolArray or StringArray with the given shape,
+// if all values are of that type, otherwise a list.
+func uniform(shape []int, values []apl.Value) apl.Value {
+	b := apl.BoolArray{Dims: shape, Bools: make([]bool, len(values))}
+	s := apl.StringArray{Dims: shape, Strings: make([]string, len(values))}
+	isbool, isstring := true, true
+	for i, v := range values {
+		switch t := v.(type) {
+		case apl.Bool:
+			b.Bools[i] = bool(t)
+			isstring = false
+		case apl.String:
+			s.Strings[i] = string(t)
+			isbool = false
+		default:
+			isbool, isstring = false, false
+		}
+	}
+	if len(values) == 0 {
+		return apl.EmptyArray{}
+	} else if isbool {
+		return b
+	} else if isstring {
+		return s
+	}
+	return apl.List(values)
+}
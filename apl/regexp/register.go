@@ -0,0 +1,35 @@
+// Package regexp provides regular expressions for strings, string arrays and channels.
+//
+// The pattern is the left argument.
+// It is a string or a compiled regular expression.
+// Functions apply to each string of a string array,
+// and to each value of a channel, such as the lines of a file.
+//
+//	compile R         compile the regular expression R
+//	P match R         does R contain a match of P
+//	P find R          all matches: a string array, or a list of (match, groups...) if P has capture groups
+//	P split R         split R into substrings separated by matches
+//	(P;S;) replace R  replace matches of P by S, which may refer to groups as $1
+//
+// Results for arrays of strings are of the same shape if they are single values,
+// otherwise they are a list with one entry for each string.
+package regexp
+
+import (
+	"github.com/ktye/iv/apl"
+)
+
+// Register adds the regexp package to the interpreter.
+func Register(a *apl.Apl, name string) {
+	if name == "" {
+		name = "rx"
+	}
+	pkg := map[string]apl.Value{
+		"compile": apl.ToFunction(compile),
+		"find":    apl.ToFunction(find),
+		"match":   apl.ToFunction(match),
+		"replace": apl.ToFunction(replace),
+		"split":   apl.ToFunction(split),
+	}
+	a.RegisterPackage(name, pkg)
+}
@@ -39,9 +39,22 @@ func (a *Apl) registerSymbol(s string) {
 
 // RegisterPackage adds an external package to apl.
 func (a *Apl) RegisterPackage(name string, m map[string]Value) {
+	a.envmu.Lock()
+	defer a.envmu.Unlock()
 	a.pkg[name] = &env{parent: nil, vars: m}
 }
 
+// SetState attaches the state of an external package to the interpreter.
+// Packages use it for data that must not be shared between interpreters, such as the io mount table.
+func (a *Apl) SetState(pkg string, v interface{}) {
+	a.state[pkg] = v
+}
+
+// State returns the value stored with SetState, or nil.
+func (a *Apl) State(pkg string) interface{} {
+	return a.state[pkg]
+}
+
 // Doc writes the documentation of all registered primitives and operators to the writer.
 func (a *Apl) Doc(w io.Writer) {
 
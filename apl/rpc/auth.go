@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Authenticator decides if a client may connect and which functions it may call.
+//
+// Authenticate is called once for each connection with the token sent by the client.
+// It returns the functions the session may call, or an error to reject the client.
+type Authenticator interface {
+	Authenticate(conn net.Conn, token string) (Allow, error)
+}
+
+// Allow reports if a session may call the function string.
+// A nil Allow permits all functions.
+type Allow func(fn string) bool
+
+// Allowlist returns an Allow that permits exactly the given function strings.
+// Leading and trailing whitespace is ignored.
+// A nil list permits all functions, an empty list none.
+func Allowlist(fns ...string) Allow {
+	if fns == nil {
+		return nil
+	}
+	m := make(map[string]bool)
+	for _, f := range fns {
+		m[strings.TrimSpace(f)] = true
+	}
+	return func(fn string) bool { return m[strings.TrimSpace(fn)] }
+}
+
+// Token is an Authenticator for a single shared token.
+// Clients that know it may call any function.
+type Token string
+
+func (t Token) Authenticate(conn net.Conn, token string) (Allow, error) {
+	if subtle.ConstantTimeCompare([]byte(t), []byte(token)) != 1 {
+		return nil, fmt.Errorf("rpc: authentication failed")
+	}
+	return nil, nil
+}
+
+// Tokens authenticates each client by its own token.
+// The map values are the function strings the client may call, nil allows all and an empty list none.
+type Tokens map[string][]string
+
+func (m Tokens) Authenticate(conn net.Conn, token string) (Allow, error) {
+	for k, fns := range m {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(token)) == 1 {
+			return Allowlist(fns...), nil
+		}
+	}
+	return nil, fmt.Errorf("rpc: authentication failed")
+}
+
+// Certificates authenticates clients by the common name of their verified tls certificate.
+// The server must require client certificates (mutual tls), e.g. with
+//	ClientAuth: tls.RequireAndVerifyClientCert
+// The map values are the function strings the client may call, nil allows all and an empty list none.
+type Certificates map[string][]string
+
+func (m Certificates) Authenticate(conn net.Conn, token string) (Allow, error) {
+	tc, ok := conn.(*tls.Conn)
+	if ok == false {
+		return nil, fmt.Errorf("rpc: authentication failed: not a tls connection")
+	}
+	if err := tc.Handshake(); err != nil {
+		return nil, err
+	}
+	st := tc.ConnectionState()
+	if len(st.VerifiedChains) == 0 {
+		return nil, fmt.Errorf("rpc: authentication failed: no verified client certificate")
+	}
+	name := st.VerifiedChains[0][0].Subject.CommonName
+	if fns, ok := m[name]; ok {
+		return Allowlist(fns...), nil
+	}
+	return nil, fmt.Errorf("rpc: authentication failed: unknown client %q", name)
+}
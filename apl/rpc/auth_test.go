@@ -0,0 +1,202 @@
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ktye/iv/apl"
+)
+
+func serve(t *testing.T, s *Server) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.New = newApl
+	go s.Serve(ln)
+	return ln.Addr().String()
+}
+
+func TestToken(t *testing.T) {
+	s := &Server{Auth: Tokens{"alpha": nil, "beta": {"+/", "⍳"}, "ci": {}}}
+	addr := serve(t, s)
+	defer s.Close()
+
+	if _, err := Dial(addr); err == nil {
+		t.Fatal("expected authentication error")
+	}
+	if _, err := (Dialer{Token: "gamma"}).Dial(addr); err == nil {
+		t.Fatal("expected authentication error")
+	}
+
+	c, err := Dialer{Token: "alpha"}.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if v, err := c.Call("-", nil, apl.Int(2)); err != nil {
+		t.Fatal(err)
+	} else if s := v.String(apl.Format{}); s != "¯2" {
+		t.Fatalf("alpha: got %s", s)
+	}
+
+	b, err := Dialer{Token: "beta"}.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if v, err := b.Call(" +/ ", nil, apl.IntArray{Ints: []int{1, 2, 3}, Dims: []int{3}}); err != nil {
+		t.Fatal(err)
+	} else if s := v.String(apl.Format{}); s != "6" {
+		t.Fatalf("beta: got %s", s)
+	}
+	if _, err := b.Call("-", nil, apl.Int(2)); err == nil || strings.Contains(err.Error(), "not allowed") == false {
+		t.Fatalf("beta: expected not allowed: %v", err)
+	}
+
+	// An empty list allows nothing.
+	ci, err := Dialer{Token: "ci"}.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ci.Close()
+	for _, fn := range []string{"-", "+/", "rpc→eval"} {
+		if _, err := ci.Call(fn, nil, apl.Int(2)); err == nil || strings.Contains(err.Error(), "not allowed") == false {
+			t.Fatalf("ci: %s: expected not allowed: %v", fn, err)
+		}
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	if Allowlist() != nil {
+		t.Fatal("nil list: expected nil")
+	}
+	for _, m := range []map[string][]string{Tokens{"ci": {}}, Certificates{"svc": []string{}}} {
+		for name, fns := range m {
+			allow := Allowlist(fns...)
+			if allow == nil || allow("+/") || allow("") {
+				t.Fatalf("%s: empty list must deny all", name)
+			}
+		}
+	}
+	if allow := Allowlist(" +/"); allow("+/ ") == false || allow("-") {
+		t.Fatal("allowlist does not match")
+	}
+}
+
+// Mutual tls with certificates for a server and two clients, signed by a test CA.
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpctls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, caKey := newCert(t, dir, "ca", nil, nil)
+	newCert(t, dir, "server", ca, caKey)
+	newCert(t, dir, "alice", ca, caKey)
+	newCert(t, dir, "eve", ca, caKey)
+
+	serverTLS, err := clientTLS("", filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverTLS.ClientCAs = x509.NewCertPool()
+	serverTLS.ClientCAs.AddCert(ca)
+	serverTLS.ClientAuth = tls.RequireAndVerifyClientCert
+
+	s := &Server{TLS: serverTLS, Auth: Certificates{"alice": {"+/"}}}
+	addr := serve(t, s)
+	defer s.Close()
+
+	// Dial from APL with the client certificate.
+	a := newApl()
+	opts := func(name string) string {
+		f := filepath.Join(dir, name)
+		return "(`ca`cert`key`name#\"" + filepath.Join(dir, "ca.pem") + "\" \"" + f + ".pem\" \"" + f + ".key\" \"localhost\")"
+	}
+	if err := a.ParseAndEval("C←" + opts("alice") + " rpc→dial \"" + addr + "\""); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ParseAndEval("R←rpc→call (C;\"+/\";⍳4;)"); err != nil {
+		t.Fatal(err)
+	}
+	if v := a.Lookup("R"); v.String(apl.Format{}) != "10" {
+		t.Fatalf("alice: got %s", v.String(apl.Format{}))
+	}
+	if err := a.ParseAndEval("rpc→call (C;\"-\";1;)"); err == nil {
+		t.Fatal("alice: - should not be allowed")
+	}
+
+	// Eve has a valid certificate, but is unknown to the server.
+	if err := a.ParseAndEval(opts("eve") + " rpc→dial \"" + addr + "\""); err == nil {
+		t.Fatal("eve should be rejected")
+	}
+
+	// A client without certificate cannot connect.
+	cfg, err := clientTLS(filepath.Join(dir, "ca.pem"), "", "", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (Dialer{TLS: cfg}).Dial(addr); err == nil {
+		t.Fatal("client without certificate should be rejected")
+	}
+}
+
+// newCert writes NAME.pem and NAME.key to dir.
+// Without a parent it creates a self-signed CA.
+func newCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(file, typ string, b []byte) {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(name+".pem", "CERTIFICATE", der)
+	write(name+".key", "EC PRIVATE KEY", k)
+	return cert, key
+}
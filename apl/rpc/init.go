@@ -13,9 +13,18 @@ func init() {
 	gob.Register(apl.Int(0))
 	gob.Register(numbers.Float(0.0))
 	gob.Register(numbers.Complex(0))
+	gob.Register(numbers.Time{})
 	gob.Register(apl.String(""))
+	gob.Register(apl.StringArray{})
 	gob.Register(apl.List(nil))
 	gob.Register(apl.MixedArray{})
 	gob.Register(apl.IntArray{})
-	gob.Register(apl.Bool(false))
+	gob.Register(apl.BoolArray{})
+	gob.Register(apl.EmptyArray{})
+	gob.Register(numbers.FloatArray{})
+	gob.Register(numbers.ComplexArray{})
+	gob.Register(numbers.TimeArray{})
+	gob.Register(&apl.Dict{})
+	gob.Register(apl.Table{})
+	gob.Register(chanRef{})
 }
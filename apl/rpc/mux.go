@@ -0,0 +1,329 @@
+package rpc
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ktye/iv/apl"
+)
+
+// After the handshake, client and server exchange frames.
+// Calls and streams are multiplexed over the connection.
+//
+// A channel that is passed as an argument or returned as a result is sent as a chanRef.
+// Its values follow as a stream of frames with the same ID.
+// The sender may send window values ahead, the receiver acknowledges each value
+// with a credit when it has been consumed.
+// When the sender's channel is closed, it ends the stream.
+// When the receiver closes its channel, the sender's channel is closed.
+//
+// Stream IDs are odd for streams sent by the client and even for those sent by the server.
+
+// window is the number of values a stream may send ahead of the receiver.
+const window = 16
+
+type op int
+
+const (
+	opCall   op = iota + 1 // client: execute Req, ID is the call id
+	opResult               // server: result V or Err for the call ID
+	opValue                // stream value V
+	opEnd                  // end of stream, with an optional Err
+	opCredit               // the receiver has consumed a value
+	opClose                // the receiver has closed the stream
+	opEval                 // client: evaluate the program Req.Fn, the result is the output
+	opLookup               // client: lookup the variable Req.Fn
+	opAssign               // client: assign Req.R to the variable Req.Fn
+	opVars                 // client: list the variables of package Req.Fn
+)
+
+// request reports if the frame is a request from the client.
+func (o op) request() bool {
+	return o == opCall || o >= opEval
+}
+
+type frame struct {
+	Op  op
+	ID  uint64
+	Req Request
+	V   apl.Value
+	Err string
+}
+
+// chanRef is sent in place of a channel.
+type chanRef struct {
+	ID uint64
+}
+
+func (r chanRef) String(f apl.Format) string { return fmt.Sprintf("rpc stream %d", r.ID) }
+func (r chanRef) Copy() apl.Value            { return r }
+
+var errClosed = errors.New("rpc: connection closed")
+var errWindow = errors.New("rpc: stream exceeds the window")
+
+// mux dispatches stream frames and sends channels.
+type mux struct {
+	enc  *gob.Encoder
+	dec  *gob.Decoder
+	wmu  sync.Mutex // lock for enc
+	mu   sync.Mutex
+	id   uint64 // last stream id
+	out  map[uint64]*outStream
+	in   map[uint64]*inStream
+	done chan struct{} // closed, when the connection is lost
+}
+
+// newMux returns a mux for the gob streams.
+// The client uses id 1, the server 2.
+func newMux(enc *gob.Encoder, dec *gob.Decoder, id uint64) *mux {
+	return &mux{
+		enc:  enc,
+		dec:  dec,
+		id:   id - 2,
+		out:  make(map[uint64]*outStream),
+		in:   make(map[uint64]*inStream),
+		done: make(chan struct{}),
+	}
+}
+
+// send writes a frame.
+// If the frame cannot be encoded, nothing is written and the connection can still be used.
+func (m *mux) send(f frame) error {
+	m.wmu.Lock()
+	defer m.wmu.Unlock()
+	return m.enc.Encode(f)
+}
+
+// read decodes frames until the connection fails.
+// Stream frames are handled by the mux, calls and results are passed to handle,
+// which must not block.
+func (m *mux) read(handle func(f frame)) error {
+	for {
+		var f frame
+		if err := m.dec.Decode(&f); err != nil {
+			m.lost()
+			return err
+		}
+		switch f.Op {
+		case opValue:
+			m.mu.Lock()
+			s := m.in[f.ID]
+			m.mu.Unlock()
+			if s != nil {
+				select {
+				case s.buf <- f.V:
+				default:
+					m.overflow(s)
+				}
+			}
+		case opEnd:
+			m.mu.Lock()
+			s := m.in[f.ID]
+			delete(m.in, f.ID)
+			m.mu.Unlock()
+			if s != nil {
+				s.err = f.Err
+				close(s.buf)
+			}
+		case opCredit, opClose:
+			m.mu.Lock()
+			s := m.out[f.ID]
+			m.mu.Unlock()
+			if s == nil {
+			} else if f.Op == opClose {
+				s.once.Do(func() { close(s.stop) })
+			} else {
+				select {
+				case s.credit <- struct{}{}:
+				default:
+				}
+			}
+		default:
+			handle(f)
+		}
+	}
+}
+
+// overflow ends an incoming stream with an error, if the sender exceeds the window.
+// The sender is told to close its channel.
+func (m *mux) overflow(s *inStream) {
+	m.mu.Lock()
+	delete(m.in, s.id)
+	m.mu.Unlock()
+	s.err = errWindow.Error()
+	close(s.buf)
+	m.send(frame{Op: opClose, ID: s.id})
+}
+
+// lost ends all incoming streams with an error and stops outgoing streams.
+func (m *mux) lost() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, s := range m.in {
+		s.err = errClosed.Error()
+		close(s.buf)
+		delete(m.in, id)
+	}
+	close(m.done)
+}
+
+// toRef replaces a channel by a chanRef.
+// The returned stream must be started after the frame containing the reference has been sent.
+func (m *mux) toRef(v apl.Value) (apl.Value, *outStream) {
+	c, ok := v.(apl.Channel)
+	if ok == false {
+		return v, nil
+	}
+	s := &outStream{
+		m:      m,
+		src:    c,
+		credit: make(chan struct{}, window),
+		stop:   make(chan struct{}),
+	}
+	for i := 0; i < window; i++ {
+		s.credit <- struct{}{}
+	}
+	m.mu.Lock()
+	m.id += 2
+	s.id = m.id
+	m.out[s.id] = s
+	m.mu.Unlock()
+	return chanRef{s.id}, s
+}
+
+// fromRef replaces a chanRef by a channel, that receives the stream.
+// It must be called from the read loop, before the next frame is read.
+func (m *mux) fromRef(v apl.Value) apl.Value {
+	r, ok := v.(chanRef)
+	if ok == false {
+		return v
+	}
+	s := &inStream{
+		m:   m,
+		id:  r.ID,
+		c:   apl.NewChannel(),
+		buf: make(chan apl.Value, window),
+	}
+	m.mu.Lock()
+	m.in[s.id] = s
+	m.mu.Unlock()
+	go s.run()
+	return s.c
+}
+
+// outStream sends the values of a local channel.
+type outStream struct {
+	m      *mux
+	id     uint64
+	src    apl.Channel
+	credit chan struct{}
+	stop   chan struct{} // closed by the receiver
+	once   sync.Once
+}
+
+func (s *outStream) run() {
+	defer func() {
+		s.m.mu.Lock()
+		delete(s.m.out, s.id)
+		s.m.mu.Unlock()
+	}()
+	end := func(err string) {
+		s.m.send(frame{Op: opEnd, ID: s.id, Err: err})
+	}
+	for {
+		select {
+		case <-s.credit:
+		case <-s.stop:
+			s.src.Close()
+			return
+		case <-s.m.done:
+			s.src.Close()
+			return
+		}
+		select {
+		case v, ok := <-s.src[0]:
+			if ok == false {
+				end("")
+				return
+			}
+			if e, ok := v.(apl.Error); ok {
+				end(e.String(apl.Format{}))
+				s.src.Close()
+				return
+			}
+			if err := s.m.send(frame{Op: opValue, ID: s.id, V: v}); err != nil {
+				end(err.Error())
+				s.src.Close()
+				return
+			}
+		case <-s.stop:
+			s.src.Close()
+			return
+		case <-s.m.done:
+			s.src.Close()
+			return
+		}
+	}
+}
+
+// inStream receives values for a local channel.
+type inStream struct {
+	m   *mux
+	id  uint64
+	c   apl.Channel
+	buf chan apl.Value
+	err string // set before buf is closed
+}
+
+func (s *inStream) run() {
+	defer close(s.c[0])
+	for {
+		select {
+		case v, ok := <-s.buf:
+			if ok == false {
+				if s.err != "" {
+					s.deliver(apl.Error{E: errors.New(s.err)})
+				}
+				return
+			}
+			if s.deliver(v) == false {
+				s.cancel()
+				return
+			}
+			s.m.send(frame{Op: opCredit, ID: s.id})
+		case _, ok := <-s.c[1]:
+			if ok == false {
+				s.cancel()
+				return
+			}
+		}
+	}
+}
+
+// deliver sends v to the local channel.
+// It returns false, if the channel has been closed.
+func (s *inStream) deliver(v apl.Value) bool {
+	for {
+		select {
+		case s.c[0] <- v:
+			return true
+		case _, ok := <-s.c[1]:
+			if ok == false {
+				return false
+			}
+		}
+	}
+}
+
+// cancel tells the sender to close its channel.
+func (s *inStream) cancel() {
+	s.m.mu.Lock()
+	_, ok := s.m.in[s.id]
+	delete(s.m.in, s.id)
+	s.m.mu.Unlock()
+	if ok {
+		s.m.send(frame{Op: opClose, ID: s.id})
+	}
+}
@@ -1,7 +1,10 @@
 package rpc
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 
 	"github.com/ktye/iv/apl"
 )
@@ -10,9 +13,15 @@ import (
 // See README.md
 func Register(a *apl.Apl, name string) {
 	pkg := map[string]apl.Value{
-		"dial":  apl.ToFunction(dial),
-		"call":  apl.ToFunction(call),
-		"close": apl.ToFunction(closeconn),
+		"dial":   apl.ToFunction(dial),
+		"call":   apl.ToFunction(call),
+		"stream": apl.ToFunction(stream),
+		"close":  apl.ToFunction(closeconn),
+		"eval":   apl.ToFunction(evalRemote),
+		"get":    apl.ToFunction(get),
+		"set":    apl.ToFunction(set),
+		"vars":   apl.ToFunction(vars),
+		"mount":  apl.ToFunction(mount),
 	}
 	if name == "" {
 		name = "rpc"
@@ -20,48 +29,127 @@ func Register(a *apl.Apl, name string) {
 	a.RegisterPackage(name, pkg)
 }
 
+// dial connects to a server.
+// The optional left argument is a dict with the keys:
+//	token	string sent to the server's authenticator
+//	ca	pem file with the certificate authority of the server, it enables tls
+//	cert	pem file with the client certificate for mutual tls
+//	key	pem file with the client key for mutual tls
+//	name	server name for the certificate verification, it enables tls
 func dial(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
-	if L != nil {
-		return nil, fmt.Errorf("rpc dial must be called monadically")
-	}
-	if s, ok := R.(apl.String); ok == false {
+	s, ok := R.(apl.String)
+	if ok == false {
 		return nil, fmt.Errorf("rpc dial: argument must be a string")
-	} else {
+	}
+	if L == nil {
 		return Dial(string(s))
 	}
+	opt, ok := L.(apl.Object)
+	if ok == false {
+		return nil, fmt.Errorf("rpc dial: left argument must be a dict: %T", L)
+	}
+	m := make(map[string]string)
+	for _, k := range opt.Keys() {
+		key, ok := k.(apl.String)
+		if ok == false {
+			return nil, fmt.Errorf("rpc dial: option keys must be strings")
+		}
+		v, ok := opt.At(k).(apl.String)
+		if ok == false {
+			return nil, fmt.Errorf("rpc dial: option %s must be a string", key)
+		}
+		m[string(key)] = string(v)
+	}
+
+	var d Dialer
+	for k, v := range m {
+		switch k {
+		case "token":
+			d.Token = v
+		case "ca", "cert", "key", "name":
+		default:
+			return nil, fmt.Errorf("rpc dial: unknown option: %s", k)
+		}
+	}
+	if m["ca"] != "" || m["cert"] != "" || m["name"] != "" {
+		cfg, err := clientTLS(m["ca"], m["cert"], m["key"], m["name"])
+		if err != nil {
+			return nil, fmt.Errorf("rpc dial: %s", err)
+		}
+		d.TLS = cfg
+	}
+	return d.Dial(string(s))
+}
+
+// clientTLS returns a tls configuration that trusts the certificate authority in the pem file ca
+// and authenticates with the certificate and key pair, if they are given.
+// If ca is empty, the system roots are used.
+func clientTLS(ca, cert, key, name string) (*tls.Config, error) {
+	cfg := &tls.Config{ServerName: name}
+	if ca != "" {
+		b, err := ioutil.ReadFile(ca)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if cfg.RootCAs.AppendCertsFromPEM(b) == false {
+			return nil, fmt.Errorf("%s: no certificates", ca)
+		}
+	}
+	if cert != "" || key != "" {
+		c, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{c}
+	}
+	return cfg, nil
 }
 
 func call(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	c, f, Larg, Rarg, err := callArgs("call", L, R)
+	if err != nil {
+		return nil, err
+	}
+	return c.Call(f, Larg, Rarg)
+}
+
+// stream is like call, but always returns a channel.
+func stream(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	c, f, Larg, Rarg, err := callArgs("stream", L, R)
+	if err != nil {
+		return nil, err
+	}
+	return c.Stream(f, Larg, Rarg)
+}
+
+// callArgs splits the list (C;f;R;) or (C;f;L;R;).
+func callArgs(name string, L, R apl.Value) (Conn, string, apl.Value, apl.Value, error) {
 	if L != nil {
-		return nil, fmt.Errorf("rpc call must be called monadically")
+		return Conn{}, "", nil, nil, fmt.Errorf("rpc %s must be called monadically", name)
 	}
 	lst, ok := R.(apl.List)
 	if ok == false {
-		return nil, fmt.Errorf("rpc call: argument must be a list: %T", R)
+		return Conn{}, "", nil, nil, fmt.Errorf("rpc %s: argument must be a list: %T", name, R)
 	}
 	if len(lst) < 3 {
-		return nil, fmt.Errorf("rpc call: argument list is too short")
+		return Conn{}, "", nil, nil, fmt.Errorf("rpc %s: argument list is too short", name)
 	}
 	if len(lst) > 4 {
-		return nil, fmt.Errorf("rpc call: argument list is too long")
+		return Conn{}, "", nil, nil, fmt.Errorf("rpc %s: argument list is too long", name)
 	}
 	c, ok := lst[0].(Conn)
 	if ok == false {
-		return nil, fmt.Errorf("rpc call: first list argument must be a connection")
+		return Conn{}, "", nil, nil, fmt.Errorf("rpc %s: first list argument must be a connection", name)
 	}
 	f, ok := lst[1].(apl.String)
 	if ok == false {
-		return nil, fmt.Errorf("rpc call: second list argument must be a string")
+		return Conn{}, "", nil, nil, fmt.Errorf("rpc %s: second list argument must be a string", name)
 	}
-
-	var Larg, Rarg apl.Value
 	if len(lst) == 3 {
-		Rarg = lst[2]
-	} else {
-		Larg = lst[2]
-		Rarg = lst[3]
+		return c, string(f), nil, lst[2], nil
 	}
-	return c.Call(string(f), Larg, Rarg)
+	return c, string(f), lst[2], lst[3], nil
 }
 
 func closeconn(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
@@ -71,3 +159,102 @@ func closeconn(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
 	}
 	return c.Close()
 }
+
+// connArgs splits the list R into a connection and n string arguments.
+// A value argument may follow the strings, if v is true.
+func connArgs(name string, R apl.Value, n int, v bool) (Conn, []string, apl.Value, error) {
+	lst, ok := R.(apl.List)
+	if ok == false {
+		return Conn{}, nil, nil, fmt.Errorf("rpc %s: argument must be a list: %T", name, R)
+	}
+	m := 1 + n
+	if v {
+		m++
+	}
+	if len(lst) != m {
+		return Conn{}, nil, nil, fmt.Errorf("rpc %s: argument list must have %d elements", name, m)
+	}
+	c, ok := lst[0].(Conn)
+	if ok == false {
+		return Conn{}, nil, nil, fmt.Errorf("rpc %s: first list argument must be a connection", name)
+	}
+	s := make([]string, n)
+	for i := range s {
+		if str, ok := lst[1+i].(apl.String); ok == false {
+			return Conn{}, nil, nil, fmt.Errorf("rpc %s: list argument %d must be a string", name, 2+i)
+		} else {
+			s[i] = string(str)
+		}
+	}
+	if v {
+		return c, s, lst[m-1], nil
+	}
+	return c, s, nil, nil
+}
+
+// evalRemote evaluates a program on the server: rpc→eval (C;"program";).
+// It returns the output of the program.
+func evalRemote(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	c, s, _, err := connArgs("eval", R, 1, false)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.Eval(s[0])
+	if err != nil {
+		return nil, err
+	}
+	return apl.String(out), nil
+}
+
+// get returns a variable from the server: rpc→get (C;"X";).
+func get(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	c, s, _, err := connArgs("get", R, 1, false)
+	if err != nil {
+		return nil, err
+	}
+	return c.Lookup(s[0])
+}
+
+// set assigns a variable on the server: rpc→set (C;"X";value;).
+func set(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	c, s, v, err := connArgs("set", R, 1, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Assign(s[0], v); err != nil {
+		return nil, err
+	}
+	return apl.Int(1), nil
+}
+
+// vars lists the variables on the server: rpc→vars C or rpc→vars (C;"pkg";).
+func vars(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	c, ok := R.(Conn)
+	pkg := ""
+	if ok == false {
+		var s []string
+		var err error
+		c, s, _, err = connArgs("vars", R, 1, false)
+		if err != nil {
+			return nil, err
+		}
+		pkg = s[0]
+	}
+	l, err := c.Vars(pkg)
+	if err != nil {
+		return nil, err
+	}
+	return apl.StringArray{Dims: []int{len(l)}, Strings: l}, nil
+}
+
+// mount mounts the variables of the server to the local file system: rpc→mount (C;"/r/";).
+func mount(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	c, s, _, err := connArgs("mount", R, 1, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := Mount(a, s[0], c); err != nil {
+		return nil, err
+	}
+	return apl.EmptyArray{}, nil
+}
@@ -2,36 +2,86 @@
 package rpc
 
 import (
+	"crypto/tls"
 	"encoding/gob"
 	"fmt"
 	"net"
+	"sync"
 
 	"github.com/ktye/iv/apl"
 )
 
+// Dial connects to the rpc server at the tcp address without tls and authentication.
 func Dial(address string) (Conn, error) {
-	c, err := net.Dial("tcp", address)
+	return Dialer{}.Dial(address)
+}
+
+// Dialer contains options for connecting to a server.
+type Dialer struct {
+	TLS   *tls.Config // TLS configures the client side of a tls connection, nil dials plain tcp.
+	Token string      // Token is sent to the server's Authenticator.
+}
+
+// Dial connects to the rpc server at the tcp address and authenticates.
+func (d Dialer) Dial(address string) (Conn, error) {
+	var c net.Conn
+	var err error
+	if d.TLS != nil {
+		c, err = tls.Dial("tcp", address, d.TLS)
+	} else {
+		c, err = net.Dial("tcp", address)
+	}
 	if err != nil {
 		return Conn{}, err
 	}
-	return Conn{Conn: c}, nil
+	return NewConn(c, d.Token)
+}
+
+// NewConn returns a client connection over an established network connection.
+// It sends the token to the server and waits for it to accept the client.
+func NewConn(c net.Conn, token string) (Conn, error) {
+	enc, dec := gob.NewEncoder(c), gob.NewDecoder(c)
+	var res Response
+	if err := enc.Encode(Hello{Token: token}); err != nil {
+		c.Close()
+		return Conn{}, err
+	} else if err := dec.Decode(&res); err != nil {
+		c.Close()
+		return Conn{}, err
+	} else if res.Err != "" {
+		c.Close()
+		return Conn{}, fmt.Errorf("%s", res.Err)
+	}
+	cn := Conn{&client{Conn: c, m: newMux(enc, dec, 1), calls: make(map[uint64]chan frame)}}
+	go cn.read()
+	return cn, nil
 }
 
+// Conn is a client connection.
+// It is safe to use from multiple goroutines.
 type Conn struct {
+	*client
+}
+
+// client keeps the gob streams for the lifetime of the connection.
+type client struct {
 	net.Conn
+	m     *mux
+	mu    sync.Mutex
+	id    uint64                // last call id
+	calls map[uint64]chan frame // pending calls
 }
 
 func (c Conn) String(f apl.Format) string {
-	remote := c.RemoteAddr()
-	if remote == nil {
+	if c.client == nil || c.RemoteAddr() == nil {
 		return fmt.Sprintf("rpc→conn not connected")
 	}
-	return fmt.Sprintf("rpc→conn to %s", remote.String())
+	return fmt.Sprintf("rpc→conn to %s", c.RemoteAddr().String())
 }
 func (c Conn) Copy() apl.Value { return c }
 
 func (c Conn) Close() (apl.Value, error) {
-	if c.Conn == nil {
+	if c.client == nil {
 		return nil, fmt.Errorf("not connected")
 	}
 	if err := c.Conn.Close(); err != nil {
@@ -40,21 +90,134 @@ func (c Conn) Close() (apl.Value, error) {
 	return apl.Int(1), nil
 }
 
+// Call executes the function string f on the server with the arguments L and R.
+// L may be nil for a monadic call.
+//
+// If L or R is a channel, its values are streamed to the server.
+// If the result is a channel, its values are streamed from the server.
 func (c Conn) Call(f string, L, R apl.Value) (apl.Value, error) {
-	req := Request{Fn: f, L: L, R: R}
-	if err := gob.NewEncoder(c.Conn).Encode(req); err != nil {
-		c.Conn.Close()
+	return c.request(opCall, f, L, R)
+}
+
+// Eval evaluates a program in the server's interpreter.
+// The program may contain multiple lines.
+// It returns the output, that the program writes.
+func (c Conn) Eval(src string) (string, error) {
+	v, err := c.request(opEval, src, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(apl.String)
+	if ok == false {
+		return "", fmt.Errorf("rpc eval: unexpected result: %T", v)
+	}
+	return string(s), nil
+}
+
+// Lookup returns the value of a variable in the server's interpreter.
+// It returns an error, if the variable does not exist.
+func (c Conn) Lookup(name string) (apl.Value, error) {
+	return c.request(opLookup, name, nil, nil)
+}
+
+// Assign sets a variable in the server's interpreter.
+func (c Conn) Assign(name string, v apl.Value) error {
+	_, err := c.request(opAssign, name, nil, v)
+	return err
+}
+
+// Vars returns the variable names of a package in the server's interpreter,
+// or of the root environment and the list of packages, if pkg is empty.
+func (c Conn) Vars(pkg string) ([]string, error) {
+	v, err := c.request(opVars, pkg, nil, nil)
+	if err != nil {
 		return nil, err
 	}
-	var res Response
-	if err := gob.NewDecoder(c.Conn).Decode(&res); err != nil {
-		c.Conn.Close()
+	s, ok := v.(apl.StringArray)
+	if ok == false {
+		return nil, fmt.Errorf("rpc vars: unexpected result: %T", v)
+	}
+	return s.Strings, nil
+}
+
+// request sends a request and waits for the result.
+func (c Conn) request(o op, f string, L, R apl.Value) (apl.Value, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	res := make(chan frame, 1)
+	c.mu.Lock()
+	select {
+	case <-c.m.done:
+		c.mu.Unlock()
+		return nil, errClosed
+	default:
+	}
+	c.id++
+	id := c.id
+	c.calls[id] = res
+	c.mu.Unlock()
+
+	L, lo := c.m.toRef(L)
+	R, ro := c.m.toRef(R)
+	err := c.m.send(frame{Op: o, ID: id, Req: Request{Fn: f, L: L, R: R}})
+	for _, o := range []*outStream{lo, ro} {
+		if o != nil {
+			go o.run()
+		}
+	}
+	if err != nil {
+		c.mu.Lock()
+		delete(c.calls, id)
+		c.mu.Unlock()
 		return nil, err
 	}
-	if res.Err != "" {
-		return nil, fmt.Errorf("%s", res.Err)
-	} else if res.V == nil {
+
+	r := <-res
+	if r.Err != "" {
+		return nil, fmt.Errorf("%s", r.Err)
+	} else if r.V == nil {
 		return nil, fmt.Errorf("empty result")
 	}
-	return res.V, nil
+	return r.V, nil
+}
+
+// Stream is like Call, but always returns a channel.
+// If the result is not a channel, the channel contains the single value.
+func (c Conn) Stream(f string, L, R apl.Value) (apl.Channel, error) {
+	v, err := c.Call(f, L, R)
+	if err != nil {
+		return apl.Channel{}, err
+	}
+	if ch, ok := v.(apl.Channel); ok {
+		return ch, nil
+	}
+	ch := apl.NewChannel()
+	go ch.SendAll([]apl.Value{v})
+	return ch, nil
+}
+
+// read dispatches results to the pending calls.
+// When the connection fails, all pending calls return an error.
+func (c *client) read() {
+	c.m.read(func(f frame) {
+		if f.Op != opResult {
+			return
+		}
+		f.V = c.m.fromRef(f.V)
+		c.mu.Lock()
+		res := c.calls[f.ID]
+		delete(c.calls, f.ID)
+		c.mu.Unlock()
+		if res != nil {
+			res <- f
+		}
+	})
+	c.Conn.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, res := range c.calls {
+		res <- frame{Err: errClosed.Error()}
+		delete(c.calls, id)
+	}
 }
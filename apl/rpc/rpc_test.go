@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/numbers"
+	"github.com/ktye/iv/apl/operators"
+	"github.com/ktye/iv/apl/primitives"
+)
+
+func newApl() *apl.Apl {
+	var b strings.Builder
+	a := apl.New(&b)
+	numbers.Register(a)
+	primitives.Register(a)
+	operators.Register(a)
+	Register(a, "")
+	return a
+}
+
+// Concurrent clients have separate sessions.
+func TestSessions(t *testing.T) {
+	s, err := Listen("localhost:0", newApl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	addr := s.Addr().String()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := Dial(addr)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer c.Close()
+			if _, err := c.Call("⍎", nil, apl.String(fmt.Sprintf("X←%d", i))); err != nil {
+				errs <- err
+				return
+			}
+			for k := 0; k < 20; k++ {
+				v, err := c.Call("+", apl.Int(k), apl.String("X"))
+				if err == nil {
+					err = fmt.Errorf("expected an error: got %v", v)
+				} else if v, err = c.Call("⍎", nil, apl.String("X")); err != nil {
+				} else if n, ok := v.(apl.Int); ok == false || int(n) != i {
+					err = fmt.Errorf("client %d: X is %v", i, v)
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+// Shutdown closes idle connections and waits for running requests.
+func TestShutdown(t *testing.T) {
+	s, err := Listen("localhost:0", newApl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := s.Addr().String()
+	idle, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idle.Call("⍳", nil, apl.Int(3)); err != nil {
+		t.Fatal(err)
+	}
+	busy, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		v, err := busy.Call("{⎕←⍵⋄⍵}", nil, apl.Int(1))
+		if err == nil && v.String(apl.Format{}) != "1" {
+			err = fmt.Errorf("busy: got %v", v)
+		}
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idle.Call("⍳", nil, apl.Int(3)); err == nil {
+		t.Fatal("idle connection should be closed")
+	}
+	if _, err := Dial(addr); err == nil {
+		t.Fatal("server should not accept connections")
+	}
+}
@@ -1,66 +1,386 @@
 package rpc
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/gob"
 	"fmt"
 	"log"
 	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ktye/iv/apl"
 )
 
+// Server serves rpc requests from many connections concurrently.
+//
+// Each connection is a session with its own interpreter, that is created by New.
+// Variables assigned by one client are not visible to others.
+//
+// If TLS is set, connections are served over tls.
+// If Auth is set, clients must authenticate before their requests are executed.
+type Server struct {
+	New  func() *apl.Apl // New returns an interpreter for a new session.
+	Log  *log.Logger     // Log is used to report connections, it may be nil.
+	TLS  *tls.Config     // TLS configures the server side of tls connections, it may be nil.
+	Auth Authenticator   // Auth authenticates clients, nil accepts anyone.
+
+	mu       sync.Mutex
+	ln       net.Listener
+	sessions map[*session]bool
+	shutdown bool
+	wg       sync.WaitGroup
+}
+
+// Listen starts a server on the tcp address.
+// It returns immediately, requests are served in the background.
+// Each connection gets a new interpreter from newApl.
+func Listen(addr string, newApl func() *apl.Apl) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{New: newApl}
+	if ln, err = s.listen(ln); err != nil {
+		return nil, err
+	}
+	go s.accept(ln, nil)
+	return s, nil
+}
+
 // ListenAndServe puts APL into server mode.
-// It accepts a single connection at a time from anyone.
-func ListenAndServe(a *apl.Apl, addr string) {
+// All connections share the interpreter a, requests are executed one at a time.
+// Use Listen for isolated sessions.
+func ListenAndServe(a *apl.Apl, addr string) error {
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer ln.Close()
+	var mu sync.Mutex
+	s := Server{New: func() *apl.Apl { return a }, Log: log.New(os.Stderr, "", log.LstdFlags)}
+	s.logf("listen on %s", addr)
+	if ln, err = s.listen(ln); err != nil {
+		return err
+	}
+	return s.accept(ln, &mu)
+}
+
+// ListenAndServe listens on the tcp address and serves connections until the server is shut down.
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts connections on the listener, until the server is shut down.
+// If TLS is set, the listener is wrapped by a tls listener.
+func (s *Server) Serve(ln net.Listener) error {
+	ln, err := s.listen(ln)
+	if err != nil {
+		return err
+	}
+	return s.accept(ln, nil)
+}
+
+// listen sets the listener, it returns the listener to accept from.
+func (s *Server) listen(ln net.Listener) (net.Listener, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shutdown {
+		ln.Close()
+		return nil, fmt.Errorf("rpc: server is shut down")
+	}
+	if s.TLS != nil {
+		ln = tls.NewListener(ln, s.TLS)
+	}
+	s.ln = ln
+	if s.sessions == nil {
+		s.sessions = make(map[*session]bool)
+	}
+	return ln, nil
+}
 
-	log.Print("listen on ", addr)
+// accept starts a session for each connection.
+// If mu is not nil, all sessions share the same interpreter and lock it for each request.
+func (s *Server) accept(ln net.Listener, mu *sync.Mutex) error {
 	for {
 		conn, err := ln.Accept()
 		if err != nil {
-			log.Print(err)
-		} else {
-			handle(a, conn)
+			s.mu.Lock()
+			shutdown := s.shutdown
+			s.mu.Unlock()
+			if shutdown {
+				return nil
+			}
+			return err
 		}
+		s.start(conn, mu)
 	}
 }
 
-type Request struct {
-	Fn   string
-	L, R apl.Value
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Addr()
 }
 
-type Response struct {
-	Err string
-	V   apl.Value
+// Close stops the server immediately and closes all connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.shutdown = true
+	var err error
+	if s.ln != nil {
+		err = s.ln.Close()
+	}
+	for c := range s.sessions {
+		c.conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
 }
 
-func handle(a *apl.Apl, cn net.Conn) {
-	log.Print("conn ", cn.RemoteAddr())
-	var req Request
-	for {
-		var res Response
-		if err := gob.NewDecoder(cn).Decode(&req); err != nil {
+// Shutdown stops the server gracefully.
+// It stops listening, closes idle connections and waits for running requests to finish.
+// If the context expires before, the remaining connections are closed and the context error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.shutdown = true
+	var err error
+	if s.ln != nil {
+		err = s.ln.Close()
+	}
+	for c := range s.sessions {
+		c.interrupt()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	}
+}
+
+func (s *Server) logf(format string, v ...interface{}) {
+	if s.Log != nil {
+		s.Log.Printf(format, v...)
+	}
+}
+
+// session is a connection with its interpreter.
+type session struct {
+	s     *Server
+	a     *apl.Apl
+	mu    *sync.Mutex // lock for the interpreter, it may be shared by all sessions
+	conn  net.Conn
+	enc   *gob.Encoder
+	dec   *gob.Decoder
+	allow Allow
+}
+
+func (s *Server) start(conn net.Conn, mu *sync.Mutex) {
+	if mu == nil {
+		mu = new(sync.Mutex)
+	}
+	c := &session{
+		s:    s,
+		mu:   mu,
+		conn: conn,
+		enc:  gob.NewEncoder(conn),
+		dec:  gob.NewDecoder(conn),
+	}
+	s.mu.Lock()
+	if s.shutdown {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	s.sessions[c] = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.sessions, c)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+		s.logf("conn %s", conn.RemoteAddr())
+		if err := c.login(); err != nil {
+			s.logf("%s: %s", conn.RemoteAddr(), err)
+			return
+		}
+		c.a = s.New()
+		c.serve()
+	}()
+}
+
+// login reads the Hello message and authenticates the client.
+// The result is sent as a Response with an empty value.
+func (c *session) login() error {
+	var h Hello
+	if err := c.dec.Decode(&h); err != nil {
+		return err
+	}
+	var res Response
+	var err error
+	if c.s.Auth != nil {
+		if c.allow, err = c.s.Auth.Authenticate(c.conn, h.Token); err != nil {
 			res.Err = err.Error()
-		} else {
-			if v, err := exec(a, req); err != nil {
-				res.Err = err.Error()
-			} else {
-				res.V = v
-			}
 		}
-		if err := gob.NewEncoder(cn).Encode(res); err != nil {
-			log.Print(err)
-			cn.Close()
+	}
+	if e := c.enc.Encode(res); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+
+	// Clear the deadline, unless the session has been interrupted during the handshake.
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	if c.s.shutdown {
+		return fmt.Errorf("rpc: server is shut down")
+	}
+	return c.conn.SetDeadline(time.Time{})
+}
+
+// interrupt stops a session reading the next request.
+// Running requests are finished, the session ends after sending their responses.
+// Open streams are closed.
+func (c *session) interrupt() {
+	c.conn.SetReadDeadline(time.Now())
+}
+
+// serve executes calls until the connection fails or is interrupted.
+func (c *session) serve() {
+	m := newMux(c.enc, c.dec, 2)
+	var calls sync.WaitGroup
+	m.read(func(f frame) {
+		if f.Op.request() == false {
 			return
 		}
+		f.Req.L = m.fromRef(f.Req.L)
+		f.Req.R = m.fromRef(f.Req.R)
+		calls.Add(1)
+		go func() {
+			defer calls.Done()
+			c.call(m, f)
+		}()
+	})
+	calls.Wait()
+}
+
+// call executes the request and sends the result.
+// A channel result is streamed to the client.
+func (c *session) call(m *mux, f frame) {
+	res := frame{Op: opResult, ID: f.ID}
+	var out *outStream
+	if v, err := c.exec(f.Op, f.Req); err != nil {
+		res.Err = err.Error()
+	} else {
+		res.V, out = m.toRef(v)
+	}
+	if err := m.send(res); err != nil {
+		// The value may not be registered with gob.
+		c.s.logf("%s", err)
+		m.send(frame{Op: opResult, ID: f.ID, Err: err.Error()})
+	}
+	if out != nil {
+		go out.run()
 	}
 }
 
+// exec executes requests one at a time.
+// Function calls run on a fork of the session interpreter:
+// a channel result may still be produced after exec returns, concurrently with the next request.
+func (c *session) exec(o op, req Request) (apl.Value, error) {
+	if c.allow != nil {
+		name, ok := opNames[o]
+		if ok == false {
+			name = req.Fn
+		}
+		if c.allow(name) == false {
+			return nil, fmt.Errorf("rpc: function is not allowed: %q", name)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch o {
+	case opEval:
+		return eval(c.a, req.Fn)
+	case opLookup:
+		if v := c.a.Lookup(req.Fn); v == nil {
+			return nil, fmt.Errorf("variable does not exist: %s", req.Fn)
+		} else {
+			return v, nil
+		}
+	case opAssign:
+		if req.R == nil {
+			return nil, fmt.Errorf("value is nil")
+		}
+		if err := c.a.Assign(req.Fn, req.R); err != nil {
+			return nil, err
+		}
+		return apl.Int(1), nil
+	case opVars:
+		l, err := c.a.Vars(req.Fn)
+		if err != nil {
+			return nil, err
+		}
+		return apl.StringArray{Dims: []int{len(l)}, Strings: l}, nil
+	default:
+		return exec(c.a.Fork(), req)
+	}
+}
+
+// opNames are checked by the Authenticator's Allow for requests other than function calls.
+var opNames = map[op]string{
+	opEval:   "rpc→eval",
+	opLookup: "rpc→get",
+	opAssign: "rpc→set",
+	opVars:   "rpc→vars",
+}
+
+// handshakeTimeout limits the time for a client to connect and authenticate.
+const handshakeTimeout = 10 * time.Second
+
+// Hello is the first message sent by a client.
+type Hello struct {
+	Token string
+}
+
+type Request struct {
+	Fn   string
+	L, R apl.Value
+}
+
+type Response struct {
+	Err string
+	V   apl.Value
+}
+
 func exec(a *apl.Apl, req Request) (apl.Value, error) {
 	if req.R == nil {
 		return nil, fmt.Errorf("right argument is nil")
@@ -77,3 +397,16 @@ func exec(a *apl.Apl, req Request) (apl.Value, error) {
 		return f.Call(a, req.L, req.R)
 	}
 }
+
+// eval evaluates a program, that may contain multiple lines.
+// It returns the output.
+func eval(a *apl.Apl, src string) (apl.Value, error) {
+	var buf bytes.Buffer
+	save := a.GetOutput()
+	a.SetOutput(&buf)
+	defer a.SetOutput(save)
+	if err := a.EvalFile(strings.NewReader(src), "rpc"); err != nil {
+		return nil, err
+	}
+	return apl.String(buf.String()), nil
+}
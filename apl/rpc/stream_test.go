@@ -0,0 +1,265 @@
+package rpc
+
+import (
+	"encoding/gob"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ktye/iv/apl"
+)
+
+// counter returns an interpreter with the function t→count,
+// that returns an endless channel 0 1 2 3 ...
+// The number of produced values is stored in n, closed is set when the channel is closed.
+func counter(n, closed *int64) func() *apl.Apl {
+	return func() *apl.Apl {
+		a := newApl()
+		a.RegisterPackage("t", map[string]apl.Value{
+			"count": apl.ToFunction(func(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+				c := apl.NewChannel()
+				go func() {
+					defer close(c[0])
+					for i := 0; ; i++ {
+						select {
+						case _, ok := <-c[1]:
+							if ok == false {
+								atomic.StoreInt64(closed, 1)
+								return
+							}
+						case c[0] <- apl.Int(i):
+							atomic.AddInt64(n, 1)
+						}
+					}
+				}()
+				return c, nil
+			}),
+		})
+		return a
+	}
+}
+
+func TestStream(t *testing.T) {
+	var n, closed int64
+	s, err := Listen("localhost:0", counter(&n, &closed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	a := newApl()
+	if err := a.ParseAndEval(fmt.Sprintf("C←rpc→dial %q", s.Addr().String())); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ParseAndEval(`X←2×¨rpc→stream (C;"t→count";0;)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ParseAndEval(`R←5↑X`); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Lookup("R").String(apl.Format{}); got != "0 2 4 6 8" {
+		t.Fatalf("got %s", got)
+	}
+
+	// Backpressure: the server stops producing values, that are not consumed.
+	time.Sleep(50 * time.Millisecond)
+	if k := atomic.LoadInt64(&n); k > 5+window+2 {
+		t.Fatalf("server produced %d values", k)
+	}
+
+	// Closing the local channel closes the server's channel.
+	if err := a.ParseAndEval(`↓X`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; atomic.LoadInt64(&closed) == 0; i++ {
+		if i == 100 {
+			t.Fatal("server channel is not closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A non-channel result is streamed as a single value.
+	if err := a.ParseAndEval(`R←1↑rpc→stream (C;"+/";⍳4;)`); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Lookup("R").String(apl.Format{}); got != "10" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+// A local channel is sent as an argument.
+func TestStreamArgument(t *testing.T) {
+	s, err := Listen("localhost:0", newApl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	c, err := Dial(s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ch := apl.NewChannel()
+	v := make([]apl.Value, 100)
+	for i := range v {
+		v[i] = apl.Int(i + 1)
+	}
+	go ch.SendAll(v)
+	r, err := c.Call("+/", nil, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.String(apl.Format{}); got != "5050" {
+		t.Fatalf("got %s", got)
+	}
+
+	// The server returns the channel, values are streamed in both directions.
+	// Errors in the channel end the stream.
+	ch = apl.NewChannel()
+	go ch.SendAll([]apl.Value{apl.Int(1), apl.Error{E: fmt.Errorf("broken")}, apl.Int(2)})
+	echo, err := c.Stream("{⍵}", nil, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := <-echo[0]; v.String(apl.Format{}) != "1" {
+		t.Fatalf("got %s", v.String(apl.Format{}))
+	}
+	if v, ok := (<-echo[0]).(apl.Error); ok == false || v.E.Error() != "broken" {
+		t.Fatalf("expected error: got %v", v)
+	}
+	if _, ok := <-echo[0]; ok {
+		t.Fatal("stream should be closed")
+	}
+
+	// A lost connection ends streams with an error.
+	res, err := c.Stream("⍳", nil, apl.Int(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := <-res[0]; v.String(apl.Format{}) != "1 2 3" {
+		t.Fatalf("got %s", v.String(apl.Format{}))
+	}
+	out := apl.NewChannel()
+	res, err = c.Stream("{⍵}", nil, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if v := <-res[0]; strings.Contains(v.String(apl.Format{}), "connection closed") == false {
+		t.Fatalf("expected error: got %s", v.String(apl.Format{}))
+	}
+}
+
+// Streams are produced while other calls of the session are executed and assign variables.
+func TestStreamOverlap(t *testing.T) {
+	s, err := Listen("localhost:0", newApl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	c, err := Dial(s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	const n = 50
+	ch := apl.NewChannel()
+	go func() {
+		defer close(ch[0])
+		for i := 0; i < n; i++ {
+			ch[0] <- apl.Int(i)
+		}
+	}()
+	if err := c.Assign("Y", apl.Int(0)); err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Stream("{(⍵×2)+0×Y}¨", nil, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := c.Assign("Y", apl.Int(i)); err != nil {
+				done <- err
+				return
+			}
+			if r, err := c.Call("{⍵+1}", nil, apl.Int(i)); err != nil {
+				done <- err
+				return
+			} else if r.String(apl.Format{}) != fmt.Sprint(i+1) {
+				done <- fmt.Errorf("got %s", r.String(apl.Format{}))
+				return
+			}
+		}
+		done <- nil
+	}()
+	i := 0
+	for v := range res[0] {
+		if got := v.String(apl.Format{}); got != fmt.Sprint(2*i) {
+			t.Fatalf("stream: expected %d got %s", 2*i, got)
+		}
+		i++
+	}
+	if i != n {
+		t.Fatalf("stream: got %d values", i)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A sender that ignores the window ends the stream with an error.
+func TestStreamWindow(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	m := newMux(gob.NewEncoder(local), gob.NewDecoder(local), 2)
+	c := m.fromRef(chanRef{ID: 1}).(apl.Channel)
+	go m.read(func(frame) {})
+
+	closed := make(chan bool)
+	go func() {
+		dec := gob.NewDecoder(remote)
+		for {
+			var f frame
+			if err := dec.Decode(&f); err != nil {
+				return
+			}
+			if f.Op == opClose && f.ID == 1 {
+				closed <- true
+			}
+		}
+	}()
+	// The receiver holds one value and buffers window values.
+	enc := gob.NewEncoder(remote)
+	for i := 0; i < window+2; i++ {
+		if err := enc.Encode(frame{Op: opValue, ID: 1, V: apl.Int(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the receiver did not close the stream")
+	}
+
+	n := 0
+	for v := range c[0] {
+		if e, ok := v.(apl.Error); ok {
+			if e.E.Error() != errWindow.Error() {
+				t.Fatalf("unexpected error: %s", e.E)
+			}
+			break
+		}
+		n++
+	}
+	if n != window+1 {
+		t.Fatalf("expected %d values before the error, got %d", window+1, n)
+	}
+}
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/ktye/iv/apl"
+	aplio "github.com/ktye/iv/apl/io"
+)
+
+// Mount mounts the variables of the server's interpreter to the file system of the local interpreter a
+// at the mount point mpt.
+// It works like the var file system of the io package, which is mounted at /v/ by default.
+//
+// Directories list variable names, packages end with a slash.
+// Reading a file returns the formatted variable.
+// Writing to a file assigns the variable, if it exists and its type implements apl.VarReader.
+func Mount(a *apl.Apl, mpt string, c Conn) error {
+	if c.client == nil {
+		return fmt.Errorf("not connected")
+	}
+	return aplio.Mount(a, mpt, varfs{a: a, c: c})
+}
+
+type varfs struct {
+	a *apl.Apl
+	c Conn
+}
+
+func (v varfs) String() string {
+	return "rpc://" + v.c.RemoteAddr().String()
+}
+
+func (v varfs) Open(name, mpt string) (io.ReadCloser, error) {
+	if name == "" || strings.HasSuffix(name, "/") {
+		pkg := strings.TrimSuffix(name, "/")
+		l, err := v.c.Vars(pkg)
+		if err != nil {
+			return nil, &os.PathError{Op: "open", Path: name, Err: err}
+		}
+		if pkg != "" {
+			pkg += "→"
+		}
+		var buf bytes.Buffer
+		for i := range l {
+			fmt.Fprintf(&buf, "%s%s%s\n", mpt, pkg, l[i])
+		}
+		return ioutil.NopCloser(&buf), nil
+	}
+	x, err := v.c.Lookup(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return ioutil.NopCloser(strings.NewReader(x.String(v.a.Format))), nil
+}
+
+func (v varfs) Write(name string) (io.WriteCloser, error) {
+	pathError := func(err error) error {
+		return &os.PathError{Op: "write", Path: name, Err: err}
+	}
+	if strings.HasSuffix(name, "/") {
+		return nil, pathError(fmt.Errorf("rpc varfs: cannot write to directory"))
+	}
+	if strings.ContainsRune(name, '→') {
+		return nil, pathError(fmt.Errorf("rpc varfs: cannot update package variable"))
+	}
+	x, err := v.c.Lookup(name)
+	if err != nil {
+		return nil, pathError(err)
+	}
+	vr, ok := x.(apl.VarReader)
+	if ok == false {
+		return nil, pathError(fmt.Errorf("rpc varfs: type is not assignable: %T", x))
+	}
+	return &varWriter{v: v, r: vr, name: name}, nil
+}
+
+// varWriter assigns the remote variable on Close.
+type varWriter struct {
+	bytes.Buffer
+	v    varfs
+	r    apl.VarReader
+	name string
+}
+
+func (w *varWriter) Close() error {
+	t := reflect.TypeOf(w.r)
+	x, err := w.r.ReadFrom(w.v.a, &w.Buffer)
+	if err != nil {
+		return err
+	}
+	if nt := reflect.TypeOf(x); nt != t {
+		return fmt.Errorf("%T ReadFrom returns a wrong type: %T", t, nt)
+	}
+	return w.v.c.Assign(w.name, x)
+}
@@ -0,0 +1,137 @@
+package rpc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ktye/iv/apl"
+	aplio "github.com/ktye/iv/apl/io"
+)
+
+func TestRemoteVars(t *testing.T) {
+	s, err := Listen("localhost:0", newApl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	a := newApl()
+	aplio.Register(a, "")
+	if err := a.ParseAndEval(fmt.Sprintf("C←rpc→dial %q", s.Addr().String())); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		in, exp string
+	}{
+		{`rpc→eval (C;"X←⍳3⋄S←\"abc\"⋄X+1";)`, "2 3 4"},
+		{`rpc→get (C;"X";)`, "1 2 3"},
+		{`rpc→set (C;"Y";5 6;)`, "1"},
+		{`rpc→eval (C;"Y×2";)`, "10 12"},
+		{`rpc→vars C`, "S X Y rpc/"},
+		{`rpc→mount (C;"/r/";)`, ""},
+	}
+	for _, tc := range testCases {
+		var b strings.Builder
+		a.SetOutput(&b)
+		if err := a.ParseAndEval(tc.in); err != nil {
+			t.Fatalf("%s: %s", tc.in, err)
+		}
+		if got := strings.TrimRight(b.String(), "\n"); got != tc.exp {
+			t.Fatalf("%s: expected %q got %q", tc.in, tc.exp, got)
+		}
+	}
+
+	// Programs may contain multiline statements.
+	c := a.Lookup("C").(Conn)
+	if out, err := c.Eval("f←{\n⍵×2\n}\nf X"); err != nil {
+		t.Fatal(err)
+	} else if out != "2 4 6\n" {
+		t.Fatalf("eval: got %q", out)
+	}
+
+	if err := a.ParseAndEval(`rpc→get (C;"Z";)`); err == nil {
+		t.Fatal("expected error for missing variable")
+	}
+	if err := a.ParseAndEval(`rpc→eval (C;"1+";)`); err == nil {
+		t.Fatal("expected error for eval")
+	}
+
+	// The remote variables are mounted at /r/.
+	read := func(name string) string {
+		r, err := aplio.Open(a, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if got := read("/r/"); got != "/r/S\n/r/X\n/r/Y\n/r/f\n/r/rpc/\n" {
+		t.Fatalf("/r/: got %q", got)
+	}
+	if got := read("/r/Y"); got != "5 6" {
+		t.Fatalf("/r/Y: got %q", got)
+	}
+	w, err := aplio.Create(a, "/r/S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(w, "alpha")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Lookup("S"); err != nil {
+		t.Fatal(err)
+	} else if v.String(apl.Format{}) != "alpha" {
+		t.Fatalf("S: got %s", v.String(apl.Format{}))
+	}
+	if _, err := aplio.Create(a, "/r/X"); err == nil {
+		t.Fatal("X should not be writable")
+	}
+
+	// Values of all types round trip.
+	for _, s := range []string{"1.5 ¯2.5", "2J1 3", "1 0 1=1 1 1", "`a`b#1 2.5", "⍉`a`b#(1 2;3 4;)", "2018.01.02", "2018.01.02 2019.03.04T12.00", "0⍴0"} {
+		if err := a.ParseAndEval("V←" + s); err != nil {
+			t.Fatalf("%s: %s", s, err)
+		}
+		v := a.Lookup("V")
+		if err := c.Assign("V", v); err != nil {
+			t.Fatalf("%s: %s", s, err)
+		}
+		r, err := c.Lookup("V")
+		if err != nil {
+			t.Fatalf("%s: %s", s, err)
+		}
+		if got, exp := r.String(a.Format), v.String(a.Format); got != exp {
+			t.Fatalf("%s: expected %s got %s", s, exp, got)
+		} else if reflect.TypeOf(r) != reflect.TypeOf(v) {
+			t.Fatalf("%s: expected %T got %T", s, v, r)
+		}
+	}
+}
+
+// Eval and variable access are restricted by the allowlist.
+func TestRemoteVarsAllow(t *testing.T) {
+	s := &Server{Auth: Tokens{"x": {"rpc→get"}}}
+	addr := serve(t, s)
+	defer s.Close()
+
+	c, err := Dialer{Token: "x"}.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.Eval("X←1"); err == nil {
+		t.Fatal("eval should not be allowed")
+	}
+	if _, err := c.Lookup("⎕IO"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,155 @@
+package scan
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A Command is a rewrite rule for a token list.
+// It is recognized by a leading / or \.
+// The identifier following is the command name.
+// Commands are added by registering packages, e.g. package a or io.
+//
+// Leading tokens of the form -name or -name=VALUE are parsed as options,
+// if the command accepts an option with that name.
+// Example:
+//	/l -p=pkg `file.apl
+type Command interface {
+	Name() string      // name without the leading slash
+	Usage() string     // synopsis, e.g. "/cd [DIR]"
+	Help() string      // one line description
+	Options() []string // names of accepted options
+	Rewrite(t []Token, opt Options) ([]Token, error)
+}
+
+// Options are parsed command options.
+// An option given without a value is stored as the number 1.
+type Options map[string]Token
+
+// RewriteFunc rewrites the tokens following a command.
+type RewriteFunc func(t []Token, opt Options) ([]Token, error)
+
+// NewCommand returns a Command that calls the rewrite function.
+func NewCommand(name, usage, help string, f RewriteFunc, options ...string) Command {
+	return command{name: name, usage: usage, help: help, f: f, options: options}
+}
+
+type command struct {
+	name, usage, help string
+	options           []string
+	f                 RewriteFunc
+}
+
+func (c command) Name() string      { return c.name }
+func (c command) Usage() string     { return c.usage }
+func (c command) Help() string      { return c.help }
+func (c command) Options() []string { return c.options }
+func (c command) Rewrite(t []Token, opt Options) ([]Token, error) {
+	return c.f(t, opt)
+}
+
+// AddCommands adds token rewrite commands.
+// A command replaces an existing command with the same name.
+func (s *Scanner) AddCommands(commands ...Command) {
+	if s.commands == nil {
+		s.commands = make(map[string]Command)
+	}
+	for _, cmd := range commands {
+		s.commands[cmd.Name()] = cmd
+	}
+}
+
+// Commands returns all commands sorted by name.
+func (s *Scanner) Commands() []Command {
+	l := make([]Command, 0, len(s.commands))
+	for _, c := range s.commands {
+		l = append(l, c)
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Name() < l[j].Name() })
+	return l
+}
+
+// applyCmds applyes rewrite rules recursively.
+// In /e/h* first /h is applyd to * then /e on the result.
+func (s *Scanner) applyCmds(t []Token) ([]Token, error) {
+	if s.commands == nil {
+		return t, nil
+	}
+	if len(t) > 1 && t[0].T == Symbol && (t[0].S == "/" || t[0].S == `\`) && t[1].T == Identifier {
+		cmd, ok := s.commands[string(t[1].S)]
+		if ok {
+			opt, t := parseOptions(cmd, t[2:])
+			t, err := s.applyCmds(t)
+			if err != nil {
+				return nil, err
+			}
+			t, err = cmd.Rewrite(t, opt)
+			if err != nil {
+				return nil, fmt.Errorf("/%s: %s", cmd.Name(), err)
+			}
+			return t, nil
+		}
+	}
+	return t, nil
+}
+
+// parseOptions splits leading options from the tokens.
+// Parsing stops at the first token that is not an option accepted by the command.
+func parseOptions(cmd Command, t []Token) (Options, []Token) {
+	accept := make(map[string]bool)
+	for _, o := range cmd.Options() {
+		accept[o] = true
+	}
+	opt := make(Options)
+	for len(t) > 1 && t[0].T == Symbol && t[0].S == "-" && t[1].T == Identifier && accept[t[1].S] {
+		name := t[1].S
+		t = t[2:]
+		if len(t) > 1 && t[0].T == Symbol && t[0].S == "=" {
+			opt[name] = t[1]
+			t = t[2:]
+		} else {
+			opt[name] = Token{T: Number, S: "1"}
+		}
+	}
+	return opt, t
+}
+
+// AddIdiom replaces a sequence of adjacent symbols with the token t.
+// Package io uses it to replace << with the copy function.
+func (s *Scanner) AddIdiom(symbols string, t Token) {
+	if s.idioms == nil {
+		s.idioms = make(map[string]Token)
+	}
+	s.idioms[symbols] = t
+}
+
+// Idioms returns the registered idioms.
+func (s *Scanner) Idioms() map[string]Token {
+	return s.idioms
+}
+
+// applyIdioms replaces the symbols of registered idioms.
+// The symbols must not be separated by blanks.
+func (s *Scanner) applyIdioms(t []Token) []Token {
+	for idiom, r := range s.idioms {
+		n := len([]rune(idiom))
+		for i := 0; i+n <= len(t); i++ {
+			if isIdiom(t[i:i+n], idiom) {
+				r.Pos = t[i].Pos
+				t = append(append(t[:i:i], r), t[i+n:]...)
+			}
+		}
+	}
+	return t
+}
+
+func isIdiom(t []Token, idiom string) bool {
+	pos := t[0].Pos
+	for i, r := range []rune(idiom) {
+		if t[i].T != Symbol || t[i].S != string(r) || t[i].Pos != pos {
+			return false
+		}
+		pos += len(t[i].S)
+	}
+	return true
+}
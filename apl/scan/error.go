@@ -0,0 +1,31 @@
+package scan
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// PosError is a scan, parse or runtime error at a position in the input.
+// Pos is the byte offset in the line, Line the line offset within a multiline statement.
+type PosError struct {
+	Pos  int
+	Line int
+	Err  error
+}
+
+func (e PosError) Error() string {
+	return e.Err.Error()
+}
+
+// Column returns the column of the error in the line starting at 1.
+func (e PosError) Column(line string) int {
+	if e.Pos > len(line) {
+		return e.Pos + 1
+	}
+	return utf8.RuneCountInString(line[:e.Pos]) + 1
+}
+
+// Caret returns the input line, with a caret below the position of the error.
+func (e PosError) Caret(line string) string {
+	return line + "\n" + strings.Repeat(" ", e.Column(line)-1) + "^"
+}
@@ -4,7 +4,6 @@ package scan
 import (
 	"fmt"
 	"io"
-	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -12,9 +11,10 @@ import (
 )
 
 type Token struct {
-	T   Type
-	S   string
-	Pos int
+	T    Type
+	S    string
+	Pos  int // byte offset of the token in the input line
+	Line int // line offset within a multiline statement, see apl.LineBuffer
 }
 
 type Type int
@@ -45,7 +45,9 @@ type Scanner struct {
 	tokens   []Token
 	symbols  map[rune]string
 	commands map[string]Command
+	idioms   map[string]Token
 	pos      int
+	start    int
 	width    int
 }
 
@@ -55,36 +57,6 @@ func (s *Scanner) SetSymbols(symbols map[rune]string) {
 	s.symbols = symbols
 }
 
-// AddCommands sets token rewrite commands.
-func (s *Scanner) AddCommands(commands map[string]Command) {
-	if s.commands == nil {
-		s.commands = make(map[string]Command)
-	}
-	for name, cmd := range commands {
-		s.commands[name] = cmd
-	}
-}
-
-// Commands returns a list of commands.
-func (s *Scanner) Commands() []string {
-	l := make([]string, len(s.commands))
-	i := 0
-	for c := range s.commands {
-		l[i] = c
-		i++
-	}
-	sort.Strings(l)
-	return l
-}
-
-// A command is a function that rewrites a token list.
-// It is recognized by a leading / or \.
-// The identifier following is the command name.
-// Commands are set by registering package a.
-type Command interface {
-	Rewrite(l []Token) []Token
-}
-
 // Scan returns the tokens from one line of APL input.
 func (s *Scanner) Scan(line string) ([]Token, error) {
 	s.input = line
@@ -92,17 +64,16 @@ func (s *Scanner) Scan(line string) ([]Token, error) {
 	s.width = 0
 	s.tokens = nil
 	for {
-		pos := s.pos
 		if t, err := s.nextToken(); err != nil {
-			return nil, err
+			return nil, PosError{Pos: s.start, Err: err}
 		} else if t.T == Endl {
 			break
 		} else {
-			t.Pos = pos
+			t.Pos = s.start
 			s.tokens = append(s.tokens, t)
 		}
 	}
-	return s.applyCmds(s.tokens), nil
+	return s.applyCmds(s.applyIdioms(s.tokens))
 }
 
 func (t Type) String() string {
@@ -209,6 +180,7 @@ func (s *Scanner) peek() rune {
 
 func (s *Scanner) nextToken() (Token, error) {
 	for {
+		s.start = s.pos
 		r, _ := s.nextRune()
 		if r == -1 {
 			return Token{T: Endl}, nil
@@ -369,22 +341,6 @@ func AllowedInVarname(r rune, first bool) bool {
 	return r == '_' || unicode.IsLetter(r)
 }
 
-// applyCmds applyes rewrite rules recursively.
-// In /e/h* first /h is applyd to * then /e on the result.
-func (s *Scanner) applyCmds(t []Token) []Token {
-	if s.commands == nil {
-		return t
-	}
-	if len(t) > 1 && t[0].T == Symbol && (t[0].S == "/" || t[0].S == `\`) && t[1].T == Identifier {
-		cmd, ok := s.commands[string(t[1].S)]
-		if ok {
-			t = s.applyCmds(t[2:])
-			return cmd.Rewrite(t)
-		}
-	}
-	return t
-}
-
 // ReadString parses the next string from the Reader.
 // The first rune must be ", ' or `.
 // Double quote parses a quoted string in the format of strconv.Quote.
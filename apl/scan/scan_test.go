@@ -94,6 +94,26 @@ func TestScan(t *testing.T) {
 	}
 }
 
+func TestIdiom(t *testing.T) {
+	var scn Scanner
+	scn.SetSymbols(map[rune]string{'<': "<"})
+	scn.AddIdiom("<<", Token{T: Identifier, S: "io→cp"})
+	testCases := []struct {
+		input, exp string
+	}{
+		{`"a"<<"b"`, "[S(a),I(io→cp),S(b)]"},
+		{`"a"< <"b"`, "[S(a),X(<),X(<),S(b)]"},
+		{"1<<<2", "[N(1),I(io→cp),X(<),N(2)]"},
+	}
+	for _, tc := range testCases {
+		if got, err := scn.Scan(tc.input); err != nil {
+			t.Fatalf("%q: %s", tc.input, err)
+		} else if s := PrintTokens(got); s != tc.exp {
+			t.Fatalf("%q: expected %s got %s", tc.input, tc.exp, s)
+		}
+	}
+}
+
 func TestScanString(t *testing.T) {
 	testCases := [][2]string{
 		// Double quoted strings with backslash escapes.
@@ -139,3 +159,66 @@ func TestScanString(t *testing.T) {
 		}
 	}
 }
+
+func TestCommand(t *testing.T) {
+	symbols := make(map[rune]string)
+	for _, r := range "+-=/" {
+		symbols[r] = string(r)
+	}
+	var scn Scanner
+	scn.SetSymbols(symbols)
+	scn.AddCommands(NewCommand("x", "/x [-n=N] ARGS", "prepend f", func(t []Token, opt Options) ([]Token, error) {
+		f := Token{T: Identifier, S: "f"}
+		if n, ok := opt["n"]; ok {
+			return append([]Token{n, f}, t...), nil
+		}
+		return append([]Token{f}, t...), nil
+	}, "n", "v"))
+
+	testCases := []struct {
+		in, exp string
+	}{
+		{"/x 1", "f 1"},
+		{"/x -n=3 1", "3 f 1"},
+		{"/x -v 1", "f 1"},
+		{"/x -a", "f - a"},
+		{"/x/x 1", "f f 1"},
+		{"/y 1", "/ y 1"},
+	}
+	for _, tc := range testCases {
+		tokens, err := scn.Scan(tc.in)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.in, err)
+		}
+		s := make([]string, len(tokens))
+		for i := range tokens {
+			s[i] = tokens[i].S
+		}
+		if got := strings.Join(s, " "); got != tc.exp {
+			t.Fatalf("%s: expected %q got %q", tc.in, tc.exp, got)
+		}
+	}
+
+	if c := scn.Commands(); len(c) != 1 || c[0].Usage() != "/x [-n=N] ARGS" {
+		t.Fatalf("unexpected commands: %v", c)
+	}
+}
+
+func TestPos(t *testing.T) {
+	symbols := map[rune]string{'+': "+", '⍳': "⍳"}
+	var scn Scanner
+	scn.SetSymbols(symbols)
+	tokens, err := scn.Scan(`⍳ 3 +  "a" alpha`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []int{0, 4, 6, 9, 13}
+	if len(tokens) != len(exp) {
+		t.Fatalf("expected %d tokens, got %d", len(exp), len(tokens))
+	}
+	for i, p := range exp {
+		if tokens[i].Pos != p {
+			t.Fatalf("token %d %q: expected pos %d, got %d", i, tokens[i].S, p, tokens[i].Pos)
+		}
+	}
+}
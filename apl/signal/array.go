@@ -0,0 +1,121 @@
+package signal
+
+import (
+	"fmt"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/domain"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+// array is a complex array with the shape of the argument.
+// Real indicates that all values of the argument were real.
+type array struct {
+	dims []int
+	v    []complex128
+	real bool
+}
+
+// toArray converts R to a complex array.
+// Scalars are converted to single element vectors.
+func toArray(a *apl.Apl, R apl.Value) (array, error) {
+	v, ok := domain.ToArray(nil).To(a, R)
+	if ok == false {
+		return array{}, fmt.Errorf("argument must be numeric: %T", R)
+	}
+	ar := v.(apl.Array)
+	x := array{dims: apl.CopyShape(ar), v: make([]complex128, ar.Size()), real: true}
+	switch t := ar.(type) {
+	case numbers.FloatArray:
+		for i, f := range t.Floats {
+			x.v[i] = complex(f, 0)
+		}
+		return x, nil
+	case numbers.ComplexArray:
+		copy(x.v, t.Cmplx)
+		x.real = false
+		return x, nil
+	}
+	for i := range x.v {
+		switch n := ar.At(i).(type) {
+		case numbers.Float:
+			x.v[i] = complex(float64(n), 0)
+		case numbers.Complex:
+			x.v[i] = complex128(n)
+			x.real = false
+		case apl.Int:
+			x.v[i] = complex(float64(n), 0)
+		case apl.Bool:
+			if n {
+				x.v[i] = 1
+			}
+		default:
+			return array{}, fmt.Errorf("argument must be numeric: %T", n)
+		}
+	}
+	return x, nil
+}
+
+// toVector converts R to a complex vector.
+func toVector(a *apl.Apl, R apl.Value) (array, error) {
+	x, err := toArray(a, R)
+	if err != nil {
+		return x, err
+	}
+	if len(x.dims) != 1 {
+		return x, fmt.Errorf("argument must be a vector")
+	}
+	return x, nil
+}
+
+// value returns a FloatArray if x is real and a ComplexArray otherwise.
+func (x array) value() apl.Value {
+	if x.real {
+		f := numbers.FloatArray{Dims: x.dims, Floats: make([]float64, len(x.v))}
+		for i, c := range x.v {
+			f.Floats[i] = real(c)
+		}
+		return f
+	}
+	return numbers.ComplexArray{Dims: x.dims, Cmplx: x.v}
+}
+
+// axis returns the axis given as the left argument, or the last axis if L is nil.
+func axis(a *apl.Apl, L apl.Value, rank int) (int, error) {
+	if L == nil {
+		return rank - 1, nil
+	}
+	v, ok := domain.ToScalar(domain.ToIndex(nil)).To(a, L)
+	if ok == false {
+		return 0, fmt.Errorf("axis must be an integer")
+	}
+	k := int(v.(apl.Int)) - a.Origin
+	if k < 0 || k >= rank {
+		return 0, fmt.Errorf("axis out of range")
+	}
+	return k, nil
+}
+
+// alongAxis calls f for each line of x along axis k.
+// The line is modified in place.
+func (x array) alongAxis(k int, f func([]complex128)) {
+	n := x.dims[k]
+	stride := 1
+	for i := k + 1; i < len(x.dims); i++ {
+		stride *= x.dims[i]
+	}
+	line := make([]complex128, n)
+	outer := len(x.v) / (n * stride)
+	for o := 0; o < outer; o++ {
+		for s := 0; s < stride; s++ {
+			off := o*n*stride + s
+			for i := range line {
+				line[i] = x.v[off+i*stride]
+			}
+			f(line)
+			for i := range line {
+				x.v[off+i*stride] = line[i]
+			}
+		}
+	}
+}
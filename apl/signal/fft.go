@@ -0,0 +1,131 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/ktye/iv/apl"
+)
+
+// fft computes the discrete fourier transform along an axis.
+// The result is always complex.
+func fft(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	return transform(a, L, R, false)
+}
+
+// ifft computes the inverse discrete fourier transform along an axis.
+// The result is scaled by 1/n.
+func ifft(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	return transform(a, L, R, true)
+}
+
+func transform(a *apl.Apl, L, R apl.Value, inverse bool) (apl.Value, error) {
+	name := "fft"
+	if inverse {
+		name = "ifft"
+	}
+	x, err := toArray(a, R)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	k, err := axis(a, L, len(x.dims))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	if len(x.v) == 0 {
+		return x.value(), nil
+	}
+	x.alongAxis(k, func(v []complex128) { dft(v, inverse) })
+	x.real = false
+	return x.value(), nil
+}
+
+// dft transforms v in place.
+// Powers of 2 use a radix-2 fft, other lengths use bluestein's algorithm.
+func dft(v []complex128, inverse bool) {
+	n := len(v)
+	if n < 2 {
+		return
+	}
+	if n&(n-1) == 0 {
+		radix2(v, inverse)
+	} else {
+		bluestein(v, inverse)
+	}
+	if inverse {
+		s := complex(1/float64(n), 0)
+		for i := range v {
+			v[i] *= s
+		}
+	}
+}
+
+// radix2 is an iterative in-place fft for power of 2 lengths without scaling.
+func radix2(v []complex128, inverse bool) {
+	n := len(v)
+	shift := uint(64 - bits.TrailingZeros(uint(n)))
+	for i := range v {
+		if j := int(bits.Reverse64(uint64(i)) >> shift); j > i {
+			v[i], v[j] = v[j], v[i]
+		}
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		w := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < half; k++ {
+				t := wk * v[start+k+half]
+				v[start+k+half] = v[start+k] - t
+				v[start+k] += t
+				wk *= w
+			}
+		}
+	}
+}
+
+// bluestein computes a dft of arbitrary length as a convolution of power of 2 length.
+func bluestein(v []complex128, inverse bool) {
+	n := len(v)
+	m := 1
+	for m < 2*n-1 {
+		m <<= 1
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+
+	// Chirp: w[k] = exp(sign·iπk²/n), k² is reduced mod 2n for accuracy.
+	w := make([]complex128, n)
+	for k := range w {
+		k2 := (k * k) % (2 * n)
+		w[k] = cmplx.Rect(1, sign*math.Pi*float64(k2)/float64(n))
+	}
+	x := make([]complex128, m)
+	y := make([]complex128, m)
+	for k := 0; k < n; k++ {
+		x[k] = v[k] * w[k]
+	}
+	y[0] = cmplx.Conj(w[0])
+	for k := 1; k < n; k++ {
+		y[k] = cmplx.Conj(w[k])
+		y[m-k] = cmplx.Conj(w[k])
+	}
+	radix2(x, false)
+	radix2(y, false)
+	for i := range x {
+		x[i] *= y[i]
+	}
+	radix2(x, true)
+	s := complex(1/float64(m), 0)
+	for k := 0; k < n; k++ {
+		v[k] = x[k] * s * w[k]
+	}
+}
@@ -0,0 +1,165 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/domain"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+// conv returns the full linear convolution of the vectors L and R.
+// The result has length (≢L)+(≢R)-1.
+func conv(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L == nil {
+		return nil, fmt.Errorf("conv: must be called dyadically")
+	}
+	l, err := toVector(a, L)
+	if err != nil {
+		return nil, fmt.Errorf("conv: %s", err)
+	}
+	r, err := toVector(a, R)
+	if err != nil {
+		return nil, fmt.Errorf("conv: %s", err)
+	}
+	if len(l.v) == 0 || len(r.v) == 0 {
+		return apl.EmptyArray{}, nil
+	}
+	n := len(l.v) + len(r.v) - 1
+	res := array{dims: []int{n}, real: l.real && r.real}
+
+	// Use the fft for long inputs.
+	if len(l.v)*len(r.v) > 4096 {
+		m := 1
+		for m < n {
+			m <<= 1
+		}
+		x := make([]complex128, m)
+		y := make([]complex128, m)
+		copy(x, l.v)
+		copy(y, r.v)
+		radix2(x, false)
+		radix2(y, false)
+		for i := range x {
+			x[i] *= y[i]
+		}
+		radix2(x, true)
+		s := complex(1/float64(m), 0)
+		for i := range x {
+			x[i] *= s
+		}
+		res.v = x[:n]
+		return res.value(), nil
+	}
+
+	res.v = make([]complex128, n)
+	for i, x := range l.v {
+		for k, y := range r.v {
+			res.v[i+k] += x * y
+		}
+	}
+	return res.value(), nil
+}
+
+// window returns a window function of length R.
+// L is the name of the window.
+func window(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	name, ok := L.(apl.String)
+	if ok == false {
+		return nil, fmt.Errorf("window: left argument must be the name of the window")
+	}
+	v, ok := domain.ToScalar(domain.ToIndex(nil)).To(a, R)
+	if ok == false || int(v.(apl.Int)) < 0 {
+		return nil, fmt.Errorf("window: right argument must be a non-negative integer")
+	}
+	n := int(v.(apl.Int))
+
+	var f func(x float64) float64
+	switch strings.ToLower(string(name)) {
+	case "rect":
+		f = func(x float64) float64 { return 1 }
+	case "hann":
+		f = func(x float64) float64 { return 0.5 - 0.5*math.Cos(2*math.Pi*x) }
+	case "hamming":
+		f = func(x float64) float64 { return 0.54 - 0.46*math.Cos(2*math.Pi*x) }
+	case "blackman":
+		f = func(x float64) float64 { return 0.42 - 0.5*math.Cos(2*math.Pi*x) + 0.08*math.Cos(4*math.Pi*x) }
+	case "bartlett":
+		f = func(x float64) float64 { return 1 - math.Abs(2*x-1) }
+	default:
+		return nil, fmt.Errorf("window: unknown window: %s", name)
+	}
+	w := numbers.FloatArray{Dims: []int{n}, Floats: make([]float64, n)}
+	for i := range w.Floats {
+		x := 0.5
+		if n > 1 {
+			x = float64(i) / float64(n-1)
+		}
+		w.Floats[i] = f(x)
+	}
+	return w, nil
+}
+
+// filter applies the rational transfer function b/a to the vector R.
+// L is the vector b of numerator coefficients for a fir filter, or a list (b;a;).
+// The filter is normalized by a[0].
+// It is implemented as a direct form II transposed structure.
+func filter(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L == nil {
+		return nil, fmt.Errorf("filter: must be called dyadically")
+	}
+	var b, d array
+	var err error
+	if lst, ok := L.(apl.List); ok {
+		if len(lst) != 2 {
+			return nil, fmt.Errorf("filter: left argument must be a list (b;a;)")
+		}
+		if b, err = toVector(a, lst[0]); err != nil {
+			return nil, fmt.Errorf("filter: b: %s", err)
+		}
+		if d, err = toVector(a, lst[1]); err != nil {
+			return nil, fmt.Errorf("filter: a: %s", err)
+		}
+	} else {
+		if b, err = toVector(a, L); err != nil {
+			return nil, fmt.Errorf("filter: %s", err)
+		}
+		d = array{dims: []int{1}, v: []complex128{1}, real: true}
+	}
+	x, err := toVector(a, R)
+	if err != nil {
+		return nil, fmt.Errorf("filter: %s", err)
+	}
+	if len(b.v) == 0 || len(d.v) == 0 || d.v[0] == 0 {
+		return nil, fmt.Errorf("filter: a[0] must not be 0")
+	}
+
+	n := len(b.v)
+	if len(d.v) > n {
+		n = len(d.v)
+	}
+	bn := make([]complex128, n)
+	an := make([]complex128, n)
+	for i := range b.v {
+		bn[i] = b.v[i] / d.v[0]
+	}
+	for i := range d.v {
+		an[i] = d.v[i] / d.v[0]
+	}
+
+	z := make([]complex128, n)
+	y := array{dims: []int{len(x.v)}, v: make([]complex128, len(x.v)), real: x.real && b.real && d.real}
+	for k, xk := range x.v {
+		yk := bn[0]*xk + z[0]
+		for i := 1; i < n; i++ {
+			z[i-1] = bn[i]*xk - an[i]*yk
+			if i < n-1 {
+				z[i-1] += z[i]
+			}
+		}
+		y.v[k] = yk
+	}
+	return y.value(), nil
+}
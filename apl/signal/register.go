@@ -0,0 +1,33 @@
+// Package signal provides fourier transforms and signal processing functions.
+//
+// Functions work on float and complex arrays.
+// Transforms are computed along the last axis, or along the axis given as the left argument.
+// Applied to a channel with each, they compute a result per record: sig→fft¨C.
+//
+//	fft R        discrete fourier transform
+//	L fft R      fourier transform along axis L
+//	ifft R       inverse fourier transform
+//	L ifft R     inverse fourier transform along axis L
+//	L conv R     linear convolution of two vectors
+//	L window R   window function of length R, L is one of: "rect" "hann" "hamming" "blackman" "bartlett"
+//	L filter R   filter vector R with the coefficients L: b or (b;a;)
+package signal
+
+import (
+	"github.com/ktye/iv/apl"
+)
+
+// Register adds the signal package to the interpreter.
+func Register(a *apl.Apl, name string) {
+	if name == "" {
+		name = "sig"
+	}
+	pkg := map[string]apl.Value{
+		"conv":   apl.ToFunction(conv),
+		"fft":    apl.ToFunction(fft),
+		"filter": apl.ToFunction(filter),
+		"ifft":   apl.ToFunction(ifft),
+		"window": apl.ToFunction(window),
+	}
+	a.RegisterPackage(name, pkg)
+}
@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+// cov returns the covariance matrix of the columns of R.
+// For a vector, it is the sample variance.
+func cov(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	return covariance(a, L, R, "cov", false)
+}
+
+// corr returns the matrix of correlation coefficients of the columns of R.
+func corr(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	return covariance(a, L, R, "corr", true)
+}
+
+func covariance(a *apl.Apl, L, R apl.Value, name string, normalize bool) (apl.Value, error) {
+	if L != nil {
+		return nil, fmt.Errorf("%s: must be called monadically", name)
+	}
+	x, err := toMatrix(a, R)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	n, m := x.rows(), x.cols()
+	if n < 2 {
+		return nil, fmt.Errorf("%s: not enough samples", name)
+	}
+	mu := make([]float64, m)
+	for i := 0; i < n; i++ {
+		for j := range mu {
+			mu[j] += x.v[i*m+j]
+		}
+	}
+	for j := range mu {
+		mu[j] /= float64(n)
+	}
+	c := make([]float64, m*m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			dj := x.v[i*m+j] - mu[j]
+			for k := j; k < m; k++ {
+				c[j*m+k] += dj * (x.v[i*m+k] - mu[k])
+			}
+		}
+	}
+	for j := 0; j < m; j++ {
+		for k := j; k < m; k++ {
+			c[j*m+k] /= float64(n - 1)
+			c[k*m+j] = c[j*m+k]
+		}
+	}
+	if normalize {
+		s := make([]float64, m)
+		for j := range s {
+			s[j] = math.Sqrt(c[j*m+j])
+		}
+		for j := 0; j < m; j++ {
+			for k := 0; k < m; k++ {
+				c[j*m+k] /= s[j] * s[k]
+			}
+		}
+	}
+	if len(x.dims) == 1 {
+		return numbers.Float(c[0]), nil
+	}
+	return numbers.FloatArray{Dims: []int{m, m}, Floats: c}, nil
+}
+
+// toMatrix converts R to a vector or a matrix with samples in rows.
+// The columns of a table are the variables.
+// A channel is collected first.
+func toMatrix(a *apl.Apl, R apl.Value) (data, error) {
+	switch r := R.(type) {
+	case apl.Table:
+		return tableMatrix(a, r)
+	case apl.Channel:
+		v, err := collect(a, r)
+		if err != nil {
+			return data{}, err
+		}
+		R = v
+	}
+	x, err := toData(a, R)
+	if err != nil {
+		return x, err
+	}
+	if len(x.dims) > 2 {
+		return x, fmt.Errorf("argument must be a vector or a matrix")
+	}
+	return x, nil
+}
+
+// tableMatrix converts the columns of a table to a matrix.
+func tableMatrix(a *apl.Apl, t apl.Table) (data, error) {
+	keys := t.Keys()
+	m := len(keys)
+	x := data{dims: []int{t.Rows, m}, v: make([]float64, t.Rows*m)}
+	for j, k := range keys {
+		c, err := toData(a, t.At(k))
+		if err != nil {
+			return data{}, fmt.Errorf("%s: %s", k.String(a.Format), err)
+		}
+		if len(c.v) != t.Rows {
+			return data{}, fmt.Errorf("%s: column must be a vector", k.String(a.Format))
+		}
+		for i, f := range c.v {
+			x.v[i*m+j] = f
+		}
+	}
+	return x, nil
+}
@@ -0,0 +1,220 @@
+package stats
+
+import (
+	"fmt"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/domain"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+// data is a float array with the shape of the argument.
+// The first axis is the sample axis, all trailing axes are columns.
+type data struct {
+	dims []int
+	v    []float64
+}
+
+// toData converts R to a float array.
+// Scalars are converted to single element vectors.
+func toData(a *apl.Apl, R apl.Value) (data, error) {
+	v, ok := domain.ToArray(nil).To(a, R)
+	if ok == false {
+		return data{}, fmt.Errorf("argument must be numeric: %T", R)
+	}
+	ar := v.(apl.Array)
+	x := data{dims: apl.CopyShape(ar), v: make([]float64, ar.Size())}
+	if len(x.dims) == 0 {
+		x.dims = []int{len(x.v)}
+	}
+	if f, ok := ar.(numbers.FloatArray); ok {
+		copy(x.v, f.Floats)
+		return x, nil
+	}
+	for i := range x.v {
+		f, err := toFloat(ar.At(i))
+		if err != nil {
+			return data{}, err
+		}
+		x.v[i] = f
+	}
+	return x, nil
+}
+
+func toFloat(v apl.Value) (float64, error) {
+	switch n := v.(type) {
+	case numbers.Float:
+		return float64(n), nil
+	case apl.Int:
+		return float64(n), nil
+	case apl.Bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("argument must be real: %T", v)
+}
+
+// rows returns the number of samples.
+func (x data) rows() int {
+	return x.dims[0]
+}
+
+// cols returns the number of columns.
+func (x data) cols() int {
+	if x.dims[0] == 0 {
+		return 0
+	}
+	return len(x.v) / x.dims[0]
+}
+
+// col returns a copy of column j.
+func (x data) col(j int) []float64 {
+	n, m := x.rows(), x.cols()
+	c := make([]float64, n)
+	for i := range c {
+		c[i] = x.v[i*m+j]
+	}
+	return c
+}
+
+// result builds the value of a column-wise function with k values per column.
+// The values are stored column by column.
+// If kdims is empty, each column reduces to a single value.
+// A vector argument with a single value per column returns a scalar.
+func (x data) result(kdims []int, v []float64) apl.Value {
+	m := x.cols()
+	k := 1
+	for _, d := range kdims {
+		k *= d
+	}
+	dims := append(append([]int{}, kdims...), x.dims[1:]...)
+	if len(dims) == 0 {
+		return numbers.Float(v[0])
+	}
+	f := numbers.FloatArray{Dims: dims, Floats: make([]float64, len(v))}
+	for j := 0; j < m; j++ {
+		for i := 0; i < k; i++ {
+			f.Floats[i*m+j] = v[j*k+i]
+		}
+	}
+	return f
+}
+
+// columnwise applies f to each column of R and returns k values per column.
+// Tables and dicts are applied per column, channels are collected first.
+func columnwise(a *apl.Apl, R apl.Value, kdims []int, f func([]float64) ([]float64, error)) (apl.Value, error) {
+	switch r := R.(type) {
+	case apl.Table:
+		return perColumn(a, r, kdims, f)
+	case apl.Object:
+		return perColumn(a, r, kdims, f)
+	case apl.Channel:
+		v, err := collect(a, r)
+		if err != nil {
+			return nil, err
+		}
+		R = v
+	}
+	x, err := toData(a, R)
+	if err != nil {
+		return nil, err
+	}
+	var res []float64
+	for j := 0; j < x.cols(); j++ {
+		v, err := f(x.col(j))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v...)
+	}
+	if x.cols() == 0 {
+		return apl.EmptyArray{}, nil
+	}
+	return x.result(kdims, res), nil
+}
+
+// perColumn applies f to each column of a table or each value of a dict.
+// Tables never contain scalars: single values are enlisted.
+func perColumn(a *apl.Apl, R apl.Value, kdims []int, f func([]float64) ([]float64, error)) (apl.Value, error) {
+	var o apl.Object
+	t, istable := R.(apl.Table)
+	if istable {
+		o = t.Dict
+	} else {
+		o = R.(apl.Object)
+	}
+	keys := o.Keys()
+	d := apl.Dict{K: make([]apl.Value, len(keys)), M: make(map[apl.Value]apl.Value)}
+	rows := 0
+	for i, k := range keys {
+		v, err := columnwise(a, o.At(k), kdims, f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", k.String(a.Format), err)
+		}
+		if _, ok := v.(apl.Array); ok == false && istable {
+			v = apl.List{v}
+		}
+		if ar, ok := v.(apl.Array); ok && i == 0 {
+			if shape := ar.Shape(); len(shape) == 1 {
+				rows = shape[0]
+			}
+		}
+		d.K[i] = k.Copy()
+		d.M[k.Copy()] = v
+	}
+	if istable {
+		return apl.Table{Dict: &d, Rows: rows}, nil
+	}
+	return &d, nil
+}
+
+// collect reads all values from a channel.
+// Each value is a record along a new first axis.
+func collect(a *apl.Apl, c apl.Channel) (apl.Value, error) {
+	var records []data
+	var err error
+	for v := range c[0] {
+		if records, err = appendRecord(a, records, v); err != nil {
+			break
+		}
+	}
+	c.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return apl.EmptyArray{}, nil
+	}
+	n := len(records[0].v)
+	dims := append([]int{len(records)}, records[0].dims...)
+	if len(records[0].dims) == 1 && n == 1 {
+		dims = dims[:1]
+	}
+	f := numbers.FloatArray{Dims: dims, Floats: make([]float64, 0, n*len(records))}
+	for _, r := range records {
+		f.Floats = append(f.Floats, r.v...)
+	}
+	return f, nil
+}
+
+// appendRecord converts a channel value and checks that all records have the same size.
+func appendRecord(a *apl.Apl, records []data, v apl.Value) ([]data, error) {
+	if e, ok := v.(apl.Error); ok {
+		return nil, e.E
+	}
+	x, err := toData(a, v)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && len(records[0].v) != len(x.v) {
+		return nil, fmt.Errorf("channel records must have the same size")
+	}
+	return append(records, x), nil
+}
+
+// floats returns a float vector.
+func floats(v []float64) apl.Value {
+	return numbers.FloatArray{Dims: []int{len(v)}, Floats: v}
+}
@@ -0,0 +1,186 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+// hist counts the values of R in the bins given by L.
+// If L is a vector of ascending bin edges, the result are the counts.
+// If L is a number of bins, they span the range of R evenly and the result is the list (counts;edges;).
+// Bins are half-open [e0, e1) except the last, which includes the right edge.
+// Values outside of the edges are not counted.
+func hist(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L == nil {
+		return nil, fmt.Errorf("hist: must be called dyadically")
+	}
+	l, err := toData(a, L)
+	if err != nil {
+		return nil, fmt.Errorf("hist: %s", err)
+	}
+	if _, ok := L.(apl.Array); ok == false {
+		return histBins(a, l.v[0], R)
+	}
+	e := l.v
+	if len(e) < 2 {
+		return nil, fmt.Errorf("hist: there must be at least 2 bin edges")
+	}
+	for i := 1; i < len(e); i++ {
+		if e[i] <= e[i-1] {
+			return nil, fmt.Errorf("hist: bin edges must be ascending")
+		}
+	}
+	if c, ok := R.(apl.Channel); ok {
+		v, err := histChannel(a, e, c)
+		if err != nil {
+			return nil, fmt.Errorf("hist: %s", err)
+		}
+		return v, nil
+	}
+	v, err := columnwise(a, R, []int{len(e) - 1}, func(x []float64) ([]float64, error) {
+		return count(e, x), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hist: %s", err)
+	}
+	return toInts(v), nil
+}
+
+// histBins divides the range of R into n bins.
+func histBins(a *apl.Apl, f float64, R apl.Value) (apl.Value, error) {
+	n := int(f)
+	if float64(n) != f || n < 1 {
+		return nil, fmt.Errorf("hist: number of bins must be a positive integer")
+	}
+	switch R.(type) {
+	case apl.Table, apl.Object:
+		return nil, fmt.Errorf("hist: use bin edges for tables and dicts")
+	case apl.Channel:
+		v, err := collect(a, R.(apl.Channel))
+		if err != nil {
+			return nil, fmt.Errorf("hist: %s", err)
+		}
+		R = v
+	}
+	x, err := toData(a, R)
+	if err != nil {
+		return nil, fmt.Errorf("hist: %s", err)
+	}
+	if len(x.v) == 0 {
+		return nil, fmt.Errorf("hist: empty argument")
+	}
+	lo, hi := x.v[0], x.v[0]
+	for _, f := range x.v {
+		if f < lo {
+			lo = f
+		}
+		if f > hi {
+			hi = f
+		}
+	}
+	if lo == hi {
+		lo, hi = lo-0.5, hi+0.5
+	}
+	e := make([]float64, n+1)
+	for i := range e {
+		e[i] = lo + float64(i)*(hi-lo)/float64(n)
+	}
+	e[n] = hi
+	v, err := columnwise(a, x.value(), []int{n}, func(x []float64) ([]float64, error) {
+		return count(e, x), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hist: %s", err)
+	}
+	return apl.List{toInts(v), floats(e)}, nil
+}
+
+// histChannel counts each element of the channel records separately.
+func histChannel(a *apl.Apl, e []float64, c apl.Channel) (apl.Value, error) {
+	k := len(e) - 1
+	var dims []int
+	var counts []int
+	var err error
+	for v := range c[0] {
+		var x data
+		if ev, ok := v.(apl.Error); ok {
+			err = ev.E
+			break
+		} else if x, err = toData(a, v); err != nil {
+			break
+		}
+		if counts == nil {
+			counts = make([]int, k*len(x.v))
+			if ar, ok := v.(apl.Array); ok {
+				dims = apl.CopyShape(ar)
+			}
+		} else if len(counts) != k*len(x.v) {
+			err = fmt.Errorf("channel records must have the same size")
+			break
+		}
+		m := len(x.v)
+		for j, f := range x.v {
+			if i := bin(e, f); i >= 0 {
+				counts[i*m+j]++
+			}
+		}
+	}
+	c.Close()
+	if err != nil {
+		return nil, err
+	} else if counts == nil {
+		return nil, fmt.Errorf("empty channel")
+	}
+	return apl.IntArray{Dims: append([]int{k}, dims...), Ints: counts}, nil
+}
+
+// count returns the number of values in each bin.
+func count(e []float64, x []float64) []float64 {
+	c := make([]float64, len(e)-1)
+	for _, f := range x {
+		if i := bin(e, f); i >= 0 {
+			c[i]++
+		}
+	}
+	return c
+}
+
+// bin returns the bin index of f or -1 if it is outside of the edges.
+func bin(e []float64, f float64) int {
+	n := len(e) - 1
+	if f < e[0] || f > e[n] || f != f {
+		return -1
+	} else if f == e[n] {
+		return n - 1
+	}
+	return sort.Search(len(e), func(i int) bool { return e[i] > f }) - 1
+}
+
+// toInts converts the float counts to integers.
+func toInts(v apl.Value) apl.Value {
+	switch t := v.(type) {
+	case numbers.FloatArray:
+		r := apl.IntArray{Dims: t.Dims, Ints: make([]int, len(t.Floats))}
+		for i, f := range t.Floats {
+			r.Ints[i] = int(f)
+		}
+		return r
+	case apl.Table:
+		toInts(t.Dict)
+		return t
+	case *apl.Dict:
+		for k, c := range t.M {
+			t.M[k] = toInts(c)
+		}
+		return t
+	}
+	return v
+}
+
+// value returns x as a FloatArray.
+func (x data) value() apl.Value {
+	return numbers.FloatArray{Dims: x.dims, Floats: x.v}
+}
@@ -0,0 +1,102 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+// linreg fits R as a linear function of the columns of L with least squares.
+// The result are the coefficients: intercept followed by one slope for each column of L.
+// If R is a matrix, each column is fitted and the coefficients are in the columns of the result.
+func linreg(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L == nil {
+		return nil, fmt.Errorf("linreg: must be called dyadically")
+	}
+	x, err := toMatrix(a, L)
+	if err != nil {
+		return nil, fmt.Errorf("linreg: %s", err)
+	}
+	y, err := toMatrix(a, R)
+	if err != nil {
+		return nil, fmt.Errorf("linreg: %s", err)
+	}
+	n, p, q := x.rows(), x.cols()+1, y.cols()
+	if y.rows() != n {
+		return nil, fmt.Errorf("linreg: arguments must have the same number of rows")
+	} else if n < p {
+		return nil, fmt.Errorf("linreg: not enough samples")
+	}
+
+	// Design matrix with a leading column of ones.
+	A := make([]float64, n*p)
+	for i := 0; i < n; i++ {
+		A[i*p] = 1
+		copy(A[i*p+1:(i+1)*p], x.v[i*(p-1):(i+1)*(p-1)])
+	}
+	b := make([]float64, n*q)
+	copy(b, y.v)
+	if err := solve(A, b, n, p, q); err != nil {
+		return nil, fmt.Errorf("linreg: %s", err)
+	}
+	if len(y.dims) == 1 {
+		return floats(b[:p]), nil
+	}
+	return numbers.FloatArray{Dims: []int{p, q}, Floats: b[:p*q]}, nil
+}
+
+// solve computes the least squares solution of A·X = B in place using householder reflections.
+// A is n×p, B is n×q, both row major. The solution is stored in the first p rows of B.
+func solve(A, B []float64, n, p, q int) error {
+	for k := 0; k < p; k++ {
+		var s float64
+		for i := k; i < n; i++ {
+			s += A[i*p+k] * A[i*p+k]
+		}
+		s = math.Sqrt(s)
+		if s == 0 {
+			return fmt.Errorf("matrix is rank deficient")
+		}
+		if A[k*p+k] > 0 {
+			s = -s
+		}
+		// v = x - s·e1 is stored in column k of A.
+		A[k*p+k] -= s
+		var vv float64
+		for i := k; i < n; i++ {
+			vv += A[i*p+k] * A[i*p+k]
+		}
+		reflect := func(M []float64, w, j int) {
+			var d float64
+			for i := k; i < n; i++ {
+				d += A[i*p+k] * M[i*w+j]
+			}
+			d *= 2 / vv
+			for i := k; i < n; i++ {
+				M[i*w+j] -= d * A[i*p+k]
+			}
+		}
+		for j := k + 1; j < p; j++ {
+			reflect(A, p, j)
+		}
+		for j := 0; j < q; j++ {
+			reflect(B, q, j)
+		}
+		// The diagonal of R.
+		A[k*p+k] = s
+	}
+
+	// Back substitution.
+	for j := 0; j < q; j++ {
+		for k := p - 1; k >= 0; k-- {
+			f := B[k*q+j]
+			for i := k + 1; i < p; i++ {
+				f -= A[k*p+i] * B[i*q+j]
+			}
+			B[k*q+j] = f / A[k*p+k]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/domain"
+	"github.com/ktye/iv/apl/numbers"
+)
+
+// mean returns the arithmetic mean.
+func mean(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L != nil {
+		return nil, fmt.Errorf("mean: must be called monadically")
+	}
+	if c, ok := R.(apl.Channel); ok {
+		w, err := accumulate(a, c)
+		if err != nil {
+			return nil, fmt.Errorf("mean: %s", err)
+		}
+		return w.value(w.mean), nil
+	}
+	v, err := columnwise(a, R, nil, func(x []float64) ([]float64, error) {
+		var w welford
+		w.init(1)
+		for _, f := range x {
+			w.add([]float64{f})
+		}
+		return w.mean, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("mean: %s", err)
+	}
+	return v, nil
+}
+
+// variance returns the variance with L delta degrees of freedom.
+// The default is 1, which is the sample variance.
+func variance(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	return moment(a, L, R, "var", false)
+}
+
+// std returns the standard deviation, the square root of var.
+func std(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	return moment(a, L, R, "std", true)
+}
+
+func moment(a *apl.Apl, L, R apl.Value, name string, sqrt bool) (apl.Value, error) {
+	ddof := 1
+	if L != nil {
+		v, ok := domain.ToScalar(domain.ToIndex(nil)).To(a, L)
+		if ok == false || int(v.(apl.Int)) < 0 {
+			return nil, fmt.Errorf("%s: left argument must be a non-negative integer", name)
+		}
+		ddof = int(v.(apl.Int))
+	}
+	if c, ok := R.(apl.Channel); ok {
+		w, err := accumulate(a, c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		v, err := w.variance(ddof, sqrt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		return w.value(v), nil
+	}
+	v, err := columnwise(a, R, nil, func(x []float64) ([]float64, error) {
+		var w welford
+		w.init(1)
+		for _, f := range x {
+			w.add([]float64{f})
+		}
+		return w.variance(ddof, sqrt)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return v, nil
+}
+
+// welford accumulates mean and variance in a single pass.
+// It keeps a separate state for each element of a record.
+type welford struct {
+	dims []int
+	n    int
+	mean []float64
+	m2   []float64
+}
+
+func (w *welford) init(size int) {
+	w.mean = make([]float64, size)
+	w.m2 = make([]float64, size)
+}
+
+func (w *welford) add(x []float64) {
+	w.n++
+	for i, f := range x {
+		d := f - w.mean[i]
+		w.mean[i] += d / float64(w.n)
+		w.m2[i] += d * (f - w.mean[i])
+	}
+}
+
+func (w *welford) variance(ddof int, sqrt bool) ([]float64, error) {
+	if w.n-ddof <= 0 {
+		return nil, fmt.Errorf("not enough samples")
+	}
+	v := make([]float64, len(w.m2))
+	for i, m := range w.m2 {
+		v[i] = m / float64(w.n-ddof)
+		if sqrt {
+			v[i] = math.Sqrt(v[i])
+		}
+	}
+	return v, nil
+}
+
+// value returns v with the shape of a channel record.
+func (w *welford) value(v []float64) apl.Value {
+	if len(w.dims) == 0 {
+		return numbers.Float(v[0])
+	}
+	return numbers.FloatArray{Dims: w.dims, Floats: v}
+}
+
+// accumulate consumes a channel with an online algorithm.
+// All records must have the same size.
+func accumulate(a *apl.Apl, c apl.Channel) (*welford, error) {
+	var w welford
+	var err error
+	for v := range c[0] {
+		var x data
+		if e, ok := v.(apl.Error); ok {
+			err = e.E
+			break
+		} else if x, err = toData(a, v); err != nil {
+			break
+		}
+		if w.n == 0 {
+			w.init(len(x.v))
+			if ar, ok := v.(apl.Array); ok {
+				w.dims = apl.CopyShape(ar)
+			}
+		} else if len(x.v) != len(w.mean) {
+			err = fmt.Errorf("channel records must have the same size")
+			break
+		}
+		w.add(x.v)
+	}
+	c.Close()
+	if err != nil {
+		return nil, err
+	} else if w.n == 0 {
+		return nil, fmt.Errorf("empty channel")
+	}
+	return &w, nil
+}
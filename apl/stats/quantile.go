@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ktye/iv/apl"
+)
+
+// median returns the median, which is the 0.5 quantile.
+func median(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L != nil {
+		return nil, fmt.Errorf("median: must be called monadically")
+	}
+	v, err := columnwise(a, R, nil, func(x []float64) ([]float64, error) {
+		return quantiles(x, []float64{0.5})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("median: %s", err)
+	}
+	return v, nil
+}
+
+// quantile returns the quantiles of R for the probabilities L.
+// For a vector L, the quantiles are along a new first axis.
+func quantile(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	if L == nil {
+		return nil, fmt.Errorf("quantile: must be called dyadically")
+	}
+	p, err := toData(a, L)
+	if err != nil {
+		return nil, fmt.Errorf("quantile: %s", err)
+	}
+	var kdims []int
+	if _, ok := L.(apl.Array); ok {
+		kdims = []int{len(p.v)}
+	}
+	for _, f := range p.v {
+		if f < 0 || f > 1 {
+			return nil, fmt.Errorf("quantile: probabilities must be within [0, 1]")
+		}
+	}
+	v, err := columnwise(a, R, kdims, func(x []float64) ([]float64, error) {
+		return quantiles(x, p.v)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("quantile: %s", err)
+	}
+	return v, nil
+}
+
+// quantiles interpolates linearly between the order statistics.
+// This is type 7 of Hyndman and Fan, which is also the default in R and numpy.
+func quantiles(x []float64, p []float64) ([]float64, error) {
+	if len(x) == 0 {
+		return nil, fmt.Errorf("empty argument")
+	}
+	s := make([]float64, len(x))
+	copy(s, x)
+	sort.Float64s(s)
+	q := make([]float64, len(p))
+	for i, f := range p {
+		h := f * float64(len(s)-1)
+		lo := math.Floor(h)
+		k := int(lo)
+		if k+1 < len(s) {
+			q[i] = s[k] + (h-lo)*(s[k+1]-s[k])
+		} else {
+			q[i] = s[k]
+		}
+	}
+	return q, nil
+}
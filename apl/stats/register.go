@@ -0,0 +1,41 @@
+// Package stats provides statistical functions.
+//
+// Functions work on numeric arrays along the first axis, such that each column of a matrix is a sample.
+// Applied to a table, they work on each column and return a table.
+// Applied to a channel, mean, var, std and hist consume the channel with an online algorithm,
+// other functions collect all values first.
+//
+//	mean R        arithmetic mean
+//	var R         sample variance
+//	0 var R       population variance, L is the delta degrees of freedom
+//	std R         standard deviation, L as for var
+//	median R      median
+//	L quantile R  quantiles for probabilities L with linear interpolation
+//	L hist R      histogram with bin edges L, or (counts;edges;) for a number of bins L
+//	cov R         covariance matrix of the columns of R
+//	corr R        correlation matrix of the columns of R
+//	L linreg R    linear regression of R on the columns of L: coefficients (intercept, slopes...)
+package stats
+
+import (
+	"github.com/ktye/iv/apl"
+)
+
+// Register adds the stats package to the interpreter.
+func Register(a *apl.Apl, name string) {
+	if name == "" {
+		name = "st"
+	}
+	pkg := map[string]apl.Value{
+		"corr":     apl.ToFunction(corr),
+		"cov":      apl.ToFunction(cov),
+		"hist":     apl.ToFunction(hist),
+		"linreg":   apl.ToFunction(linreg),
+		"mean":     apl.ToFunction(mean),
+		"median":   apl.ToFunction(median),
+		"quantile": apl.ToFunction(quantile),
+		"std":      apl.ToFunction(std),
+		"var":      apl.ToFunction(variance),
+	}
+	a.RegisterPackage(name, pkg)
+}
@@ -70,6 +70,9 @@ func (a *Apl) AssignEnv(name string, v Value, env *env) error {
 		env = a.env
 	}
 
+	a.envmu.Lock()
+	defer a.envmu.Unlock()
+
 	// Special case: Default left argument in lambda expressions:
 	// Do not overwrite the given argument.
 	if name == "⍺" && env.vars["⍺"] != nil {
@@ -106,6 +109,8 @@ func (a *Apl) LookupEnv(name string) (Value, *env) {
 		}
 	}
 
+	a.envmu.RLock()
+	defer a.envmu.RUnlock()
 	e := a.env
 	for {
 		v, ok := e.vars[name]
@@ -124,6 +129,8 @@ func (a *Apl) LookupEnv(name string) (Value, *env) {
 // If pkg is empty, the variables the root environment are returned,
 // and a list of packages ending with "/".
 func (a *Apl) Vars(pkg string) ([]string, error) {
+	a.envmu.RLock()
+	defer a.envmu.RUnlock()
 	var l []string
 	var e *env
 	if pkg == "" {
@@ -152,6 +159,8 @@ func (a *Apl) packageVar(name string) Value {
 	}
 	pkgname := name[:idx]
 	varname := name[idx+len("→"):]
+	a.envmu.RLock()
+	defer a.envmu.RUnlock()
 	pkg, ok := a.pkg[pkgname]
 	if ok == false {
 		return nil
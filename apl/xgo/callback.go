@@ -0,0 +1,123 @@
+package xgo
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/ktye/iv/apl"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// exportFunc converts an apl function to a go func of type t.
+//
+// The go func calls back into the interpreter.
+// Arguments are passed depending on their number:
+//	0: R is an empty array
+//	1: R is the argument
+//	2: L and R are the first and second argument, e.g. {⍺<⍵} for a less function
+//	n: R is a vector of all arguments
+// Multiple results must be returned as a vector or list of the same length.
+// If the last result is an error, an apl error is returned in it.
+// Otherwise the go func returns zero values and the first error is recorded in cb,
+// which is reported by Function.Call after the go function returns.
+// Without cb, t must have an error result.
+func exportFunc(a *apl.Apl, f apl.Function, t reflect.Type, cb *callbacks) (reflect.Value, error) {
+	if xf, ok := f.(Function); ok && xf.Fn.Type().ConvertibleTo(t) {
+		return xf.Fn.Convert(t), nil
+	}
+	if a == nil {
+		return reflect.Value{}, fmt.Errorf("cannot convert function to %v without an interpreter", t)
+	}
+
+	nout := t.NumOut()
+	haserr := nout > 0 && t.Out(nout-1) == errorType
+	if haserr {
+		nout--
+	} else if cb == nil {
+		return reflect.Value{}, fmt.Errorf("cannot convert function to %v: an error result is required", t)
+	}
+	fail := func(err error) []reflect.Value {
+		out := make([]reflect.Value, t.NumOut())
+		for i := range out {
+			out[i] = reflect.Zero(t.Out(i))
+		}
+		if haserr {
+			out[nout] = reflect.ValueOf(&err).Elem()
+		} else {
+			cb.fail(err)
+		}
+		return out
+	}
+
+	fn := func(in []reflect.Value) []reflect.Value {
+		args := make([]apl.Value, len(in))
+		for i := range in {
+			v, err := Convert(in[i])
+			if err != nil {
+				return fail(fmt.Errorf("callback argument %d: %s", i+1, err))
+			}
+			args[i] = v
+		}
+		var L, R apl.Value
+		switch len(args) {
+		case 0:
+			R = apl.EmptyArray{}
+		case 1:
+			R = args[0]
+		case 2:
+			L, R = args[0], args[1]
+		default:
+			R = uniform(args)
+		}
+
+		res, err := f.Call(a, L, R)
+		if err != nil {
+			return fail(err)
+		}
+
+		out := make([]reflect.Value, t.NumOut())
+		if haserr {
+			out[nout] = reflect.Zero(errorType)
+		}
+		if nout == 1 {
+			if out[0], err = export(a, res, t.Out(0), cb); err != nil {
+				return fail(fmt.Errorf("callback result: %s", err))
+			}
+		} else if nout > 1 {
+			ar, ok := res.(apl.Array)
+			if ok == false || ar.Size() != nout {
+				return fail(fmt.Errorf("callback must return %d results", nout))
+			}
+			for i := 0; i < nout; i++ {
+				if out[i], err = export(a, ar.At(i), t.Out(i), cb); err != nil {
+					return fail(fmt.Errorf("callback result %d: %s", i+1, err))
+				}
+			}
+		}
+		return out
+	}
+	return reflect.MakeFunc(t, fn), nil
+}
+
+// callbacks records the first error of failing callbacks without an error result.
+type callbacks struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (c *callbacks) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// Err returns the first recorded error.
+func (c *callbacks) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
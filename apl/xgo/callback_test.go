@@ -0,0 +1,41 @@
+package xgo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ktye/iv/apl"
+)
+
+// A failing callback without an error result is returned as an error.
+func TestCallbackError(t *testing.T) {
+	a := apl.New(nil)
+	fields := Function{Name: "fields", Fn: reflect.ValueOf(strings.FieldsFunc)}
+	fail := apl.ToFunction(func(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+		return nil, fmt.Errorf("callback fails")
+	})
+	_, err := fields.Call(a, nil, apl.List{apl.String("a,b"), fail})
+	if err == nil || err.Error() != "callback fails" {
+		t.Fatalf("expected callback error: got %v", err)
+	}
+}
+
+// Outside of a function call, a callback must be able to return an error.
+func TestCallbackWithoutCall(t *testing.T) {
+	a := apl.New(nil)
+	f := apl.ToFunction(func(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+		return nil, fmt.Errorf("callback fails")
+	})
+	if _, err := exportFunc(a, f, reflect.TypeOf(func(rune) bool { return false }), nil); err == nil {
+		t.Fatal("expected an error for a func without an error result")
+	}
+	v, err := exportFunc(a, f, reflect.TypeOf(func(rune) (bool, error) { return false, nil }), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Interface().(func(rune) (bool, error))('a'); err == nil || err.Error() != "callback fails" {
+		t.Fatalf("expected callback error: got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package xgo
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ktye/iv/apl"
+)
+
+// convertChan bridges a go channel to an apl.Channel.
+//
+// A channel that can be received from is a source:
+// it's values are converted and can be read from C[0], e.g. with ↑C or f¨C.
+// The go channel should be closed by the sender, closing C stops forwarding.
+// Remaining values are received and discarded, until the sender closes the go channel.
+//
+// A send-only channel is a sink: values sent with C↓R are converted and written to it.
+// They are queued, if the go side is not ready.
+// The go channel is closed when C is closed with ↓C, after the queued values have been sent.
+func convertChan(v reflect.Value) apl.Channel {
+	c := apl.NewChannel()
+	if v.Type().ChanDir()&reflect.RecvDir == 0 {
+		go sendChan(c, v)
+	} else {
+		go recvChan(c, v)
+	}
+	return c
+}
+
+func recvChan(c apl.Channel, v reflect.Value) {
+	defer close(c[0])
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c[1])},
+		{Dir: reflect.SelectRecv, Chan: v},
+	}
+	for {
+		i, x, ok := reflect.Select(cases)
+		if i == 0 {
+			if ok == false {
+				go drain(v)
+				return
+			}
+			continue
+		} else if ok == false {
+			return
+		}
+		r, err := Convert(x)
+		if err != nil {
+			r = apl.Error{E: err}
+		}
+		if deliver(c, r) == false || err != nil {
+			go drain(v)
+			return
+		}
+	}
+}
+
+// deliver sends r to C[0].
+// It returns false, if C[1] is closed.
+func deliver(c apl.Channel, r apl.Value) bool {
+	for {
+		select {
+		case _, ok := <-c[1]:
+			if ok == false {
+				return false
+			}
+		case c[0] <- r:
+			return true
+		}
+	}
+}
+
+// drain receives from the go channel v until it is closed,
+// so that the sender does not block.
+func drain(v reflect.Value) {
+	for {
+		if _, ok := v.Recv(); ok == false {
+			return
+		}
+	}
+}
+
+func sendChan(c apl.Channel, v reflect.Value) {
+	var q []reflect.Value
+	stop := func() {
+		close(c[0])
+		for _, x := range q {
+			v.Send(x)
+		}
+		v.Close()
+	}
+	t := v.Type().Elem()
+	for {
+		cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c[1])}}
+		if len(q) > 0 {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: v, Send: q[0]})
+		}
+		i, r, ok := reflect.Select(cases)
+		if i == 1 {
+			q = q[1:]
+			continue
+		} else if ok == false {
+			stop()
+			return
+		}
+		x, err := export(nil, r.Interface().(apl.Value), t, nil)
+		if err != nil {
+			e := apl.Error{E: fmt.Errorf("send to %v: %s", v.Type(), err)}
+			if deliver(c, e) {
+				for range c[1] {
+				}
+			}
+			stop()
+			return
+		}
+		q = append(q, x)
+	}
+}
+
+// exportChan creates a go channel of type t that is connected to the apl.Channel c.
+//
+// If the go side receives, values are read from C[0], converted and sent.
+// The go channel is closed, when C[0] is closed, when C is closed with ↓C,
+// or when a value cannot be converted, which also closes C.
+// The conversion error is sent to C[1] as an apl.Error.
+//
+// If the go channel is send-only, the go side writes to it
+// and the values are sent to C, as with C↓R.
+// When the go side closes the channel, C[1] is closed.
+// When C[0] is closed, further values from the go side are discarded.
+func exportChan(a *apl.Apl, c apl.Channel, t reflect.Type) reflect.Value {
+	v := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, t.Elem()), 0)
+	if t.ChanDir() == reflect.SendDir {
+		go func() {
+			defer close(c[1])
+			for {
+				x, ok := v.Recv()
+				if ok == false {
+					return
+				}
+				r, err := Convert(x)
+				if err != nil {
+					r = apl.Error{E: err}
+				}
+				if send(c, r) == false {
+					for ok {
+						_, ok = v.Recv()
+					}
+					return
+				}
+			}
+		}()
+	} else {
+		go func() {
+			for r := range c[0] {
+				x, err := export(a, r, t.Elem(), nil)
+				if err != nil {
+					v.Close()
+					e := apl.Error{E: fmt.Errorf("receive from %v: %s", t, err)}
+					if send(c, e) {
+						c.Close()
+					} else {
+						close(c[1])
+					}
+					return
+				}
+				if sendGo(c, v, x) == false {
+					v.Close()
+					return
+				}
+			}
+			v.Close()
+		}()
+	}
+	return v.Convert(t)
+}
+
+// send writes r to C[1] until C[0] is closed.
+// Values received from C[0] meanwhile are discarded.
+// It returns false, if C[0] is closed.
+func send(c apl.Channel, r apl.Value) bool {
+	for {
+		select {
+		case c[1] <- r:
+			return true
+		case _, ok := <-c[0]:
+			if ok == false {
+				return false
+			}
+		}
+	}
+}
+
+// sendGo sends x to the go channel v until C[1] is closed.
+// Values received from C[1] meanwhile are discarded.
+// It returns false, if C[1] is closed.
+func sendGo(c apl.Channel, v, x reflect.Value) bool {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: v, Send: x},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c[1])},
+	}
+	for {
+		if i, _, ok := reflect.Select(cases); i == 0 {
+			return true
+		} else if ok == false {
+			return false
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package xgo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ktye/iv/apl"
+)
+
+// A value that cannot be converted is reported to the apl side.
+func TestExportChanError(t *testing.T) {
+	c := apl.NewChannel()
+	report := make(chan apl.Value, 1)
+	go func() {
+		defer close(c[0])
+		for {
+			select {
+			case v, ok := <-c[1]:
+				if ok == false {
+					return
+				}
+				report <- v
+			case c[0] <- apl.String("x"):
+			}
+		}
+	}()
+	v := exportChan(apl.New(nil), c, reflect.TypeOf((<-chan float64)(nil)))
+	for x := range v.Interface().(<-chan float64) {
+		t.Fatalf("unexpected value %v", x)
+	}
+	if e, ok := (<-report).(apl.Error); ok == false || e.E == nil {
+		t.Fatal("expected an apl.Error")
+	}
+}
+
+// The go side may continue to send, after the apl side is closed.
+func TestExportChanClosed(t *testing.T) {
+	c := apl.NewChannel()
+	close(c[0])
+	v := exportChan(apl.New(nil), c, reflect.TypeOf((chan<- string)(nil)))
+	gc := v.Interface().(chan<- string)
+	for i := 0; i < 3; i++ {
+		gc <- "x"
+	}
+	close(gc)
+	if _, ok := <-c[1]; ok {
+		t.Fatal("C[1] should be closed")
+	}
+}
+
+// Closing a source does not block the go sender.
+func TestRecvChanClosed(t *testing.T) {
+	gc, done := make(chan int), make(chan bool)
+	go func() {
+		for i := 0; i < 5; i++ {
+			gc <- i
+		}
+		close(gc)
+		close(done)
+	}()
+	c := convertChan(reflect.ValueOf((<-chan int)(gc)))
+	<-c[0]
+	c.Close()
+	wait(t, done)
+}
+
+// Closing C returns, if the go side stops receiving.
+func TestStoppedReceiver(t *testing.T) {
+	sink := make(chan int)
+	c := convertChan(reflect.ValueOf((chan<- int)(sink)))
+	closed := make(chan bool)
+	go func() {
+		c[1] <- apl.Int(1)
+		c[1] <- apl.Int(2)
+		c.Close()
+		close(closed)
+	}()
+	wait(t, closed)
+
+	c = apl.NewChannel()
+	go c.SendAll([]apl.Value{apl.Int(1), apl.Int(2), apl.Int(3)})
+	v := exportChan(apl.New(nil), c, reflect.TypeOf((<-chan int)(nil)))
+	<-v.Interface().(<-chan int)
+	closed = make(chan bool)
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+	wait(t, closed)
+}
+
+func wait(t *testing.T, c chan bool) {
+	select {
+	case <-c:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -3,6 +3,8 @@ package xgo
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"time"
 
 	"github.com/ktye/iv/apl"
 	"github.com/ktye/iv/apl/numbers"
@@ -13,32 +15,117 @@ type Exporter interface {
 	Export() reflect.Value
 }
 
+var timeType = reflect.TypeOf(time.Time{})
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// y0 is the zero time of a duration, see numbers.Time.
+var y0 = time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+
 // export converts an apl value to a go value.
-func export(v apl.Value, t reflect.Type) (reflect.Value, error) {
+// Failing callbacks of converted apl functions are recorded in cb, see exportFunc.
+func export(a *apl.Apl, v apl.Value, t reflect.Type, cb *callbacks) (reflect.Value, error) {
+	zero := reflect.Value{}
 
+	// Special types are tested first, they have a basic kind.
+	switch t {
+	case timeType:
+		if tv, ok := v.(numbers.Time); ok {
+			return reflect.ValueOf(time.Time(tv)), nil
+		}
+		return zero, fmt.Errorf("expected time: %T", v)
+	case durationType:
+		return exportDuration(v)
+	}
+
+	// An int is convertible to a string, but it should not become a rune.
 	if e, ok := v.(Exporter); ok {
-		x := e.Export()
-		if x.Type().ConvertibleTo(t) {
+		if x := e.Export(); x.Type().ConvertibleTo(t) && (t.Kind() != reflect.String || x.Kind() == reflect.String) {
 			return x.Convert(t), nil
 		}
 	}
 
-	zero := reflect.Value{}
 	switch t.Kind() {
 
-	case reflect.Int:
-		return reflect.ValueOf(int(v.(apl.Int))), nil
+	case reflect.Bool:
+		switch b := v.(type) {
+		case apl.Bool:
+			return reflect.ValueOf(bool(b)).Convert(t), nil
+		case apl.Int:
+			if b == 0 || b == 1 {
+				return reflect.ValueOf(b == 1).Convert(t), nil
+			}
+		}
+		return zero, fmt.Errorf("expected bool: %T", v)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := toInt(v)
+		if ok == false {
+			return zero, fmt.Errorf("expected integer: %T", v)
+		}
+		x := reflect.New(t).Elem()
+		if x.OverflowInt(int64(n)) {
+			return zero, fmt.Errorf("integer overflows %s: %d", t, n)
+		}
+		x.SetInt(int64(n))
+		return x, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := toInt(v)
+		if ok == false || n < 0 {
+			return zero, fmt.Errorf("expected non-negative integer: %T", v)
+		}
+		x := reflect.New(t).Elem()
+		if x.OverflowUint(uint64(n)) {
+			return zero, fmt.Errorf("integer overflows %s: %d", t, n)
+		}
+		x.SetUint(uint64(n))
+		return x, nil
 
-	case reflect.Float64:
-		return reflect.ValueOf(float64(v.(numbers.Float))), nil
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch n := v.(type) {
+		case numbers.Float:
+			f = float64(n)
+		case apl.Int:
+			f = float64(n)
+		case apl.Bool:
+			if n {
+				f = 1
+			}
+		default:
+			return zero, fmt.Errorf("expected float: %T", v)
+		}
+		x := reflect.New(t).Elem()
+		x.SetFloat(f)
+		return x, nil
 
-	case reflect.Complex128:
-		return reflect.ValueOf(complex128(v.(numbers.Complex))), nil
+	case reflect.Complex64, reflect.Complex128:
+		var c complex128
+		switch n := v.(type) {
+		case numbers.Complex:
+			c = complex128(n)
+		case numbers.Float:
+			c = complex(float64(n), 0)
+		case apl.Int:
+			c = complex(float64(n), 0)
+		default:
+			return zero, fmt.Errorf("expected complex: %T", v)
+		}
+		x := reflect.New(t).Elem()
+		x.SetComplex(c)
+		return x, nil
 
 	case reflect.String:
-		return reflect.ValueOf(string(v.(apl.String))), nil
+		s, ok := v.(apl.String)
+		if ok == false {
+			return zero, fmt.Errorf("expected string: %T", v)
+		}
+		return reflect.ValueOf(string(s)).Convert(t), nil
 
 	case reflect.Slice:
+		if tb, ok := v.(apl.Table); ok && t.Elem().Kind() == reflect.Struct {
+			return exportTable(a, tb, t, cb)
+		}
 		ar, ok := v.(apl.Array)
 		if ok == false {
 			return zero, fmt.Errorf("expected slice: %T", v)
@@ -47,7 +134,7 @@ func export(v apl.Value, t reflect.Type) (reflect.Value, error) {
 		n := ar.Size()
 		s := reflect.MakeSlice(t, n, n)
 		for i := 0; i < n; i++ {
-			if e, err := export(ar.At(i), et); err != nil {
+			if e, err := export(a, ar.At(i), et, cb); err != nil {
 				return zero, err
 			} else {
 				se := s.Index(i)
@@ -56,6 +143,37 @@ func export(v apl.Value, t reflect.Type) (reflect.Value, error) {
 		}
 		return s, nil
 
+	case reflect.Map:
+		o, ok := v.(apl.Object)
+		if ok == false {
+			return zero, fmt.Errorf("expected dict: %T", v)
+		}
+		m := reflect.MakeMap(t)
+		for _, k := range o.Keys() {
+			kv, err := export(a, k, t.Key(), cb)
+			if err != nil {
+				return zero, fmt.Errorf("map key: %s", err)
+			}
+			ev, err := export(a, o.At(k), t.Elem(), cb)
+			if err != nil {
+				return zero, fmt.Errorf("map value %s: %s", k.String(apl.Format{}), err)
+			}
+			m.SetMapIndex(kv, ev)
+		}
+		return m, nil
+
+	case reflect.Ptr:
+		if xv, ok := v.(Value); ok && reflect.Value(xv).Type() == t {
+			return reflect.Value(xv), nil
+		}
+		e, err := export(a, v, t.Elem(), cb)
+		if err != nil {
+			return zero, err
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(e)
+		return p, nil
+
 	case reflect.Struct:
 		if xv, ok := v.(Value); ok {
 			st := reflect.Value(xv).Type()
@@ -65,47 +183,333 @@ func export(v apl.Value, t reflect.Type) (reflect.Value, error) {
 				return reflect.Value(xv), nil
 			}
 		}
+		if o, ok := v.(apl.Object); ok {
+			s := reflect.New(t).Elem()
+			for _, k := range o.Keys() {
+				if err := setField(a, s, k, o.At(k), cb); err != nil {
+					return zero, err
+				}
+			}
+			return s, nil
+		}
 		return zero, fmt.Errorf("xgo: export struct: cannot convert %T to %s", v, t)
 
+	case reflect.Chan:
+		c, ok := v.(apl.Channel)
+		if ok == false {
+			return zero, fmt.Errorf("expected channel: %T", v)
+		}
+		return exportChan(a, c, t), nil
+
+	case reflect.Func:
+		f, ok := v.(apl.Function)
+		if ok == false {
+			return zero, fmt.Errorf("expected function: %T", v)
+		}
+		return exportFunc(a, f, t, cb)
+
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return zero, fmt.Errorf("cannot convert to %v", t)
+		}
+		x, err := exportAny(a, v, cb)
+		if err != nil {
+			return zero, err
+		}
+		if x.IsValid() == false {
+			return reflect.Zero(t), nil
+		}
+		return x, nil
+
 	default:
 		return zero, fmt.Errorf("cannot convert to %v (%s)", t, t.Kind())
 	}
 }
 
-// convert converts a go value to an apl value.
+// toInt converts an integral number to an int.
+func toInt(v apl.Value) (int, bool) {
+	switch n := v.(type) {
+	case apl.Int:
+		return int(n), true
+	case apl.Bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	case apl.Number:
+		return n.ToIndex()
+	}
+	return 0, false
+}
+
+// exportDuration converts a time duration or a number of seconds.
+func exportDuration(v apl.Value) (reflect.Value, error) {
+	var d time.Duration
+	switch n := v.(type) {
+	case numbers.Time:
+		d = time.Time(n).Sub(y0)
+	case apl.Int:
+		d = time.Duration(n) * time.Second
+	case numbers.Float:
+		d = time.Duration(float64(n) * float64(time.Second))
+	default:
+		return reflect.Value{}, fmt.Errorf("expected duration: %T", v)
+	}
+	return reflect.ValueOf(d), nil
+}
+
+// exportTable converts a table to a slice of structs.
+// Columns are matched to the fields by name.
+func exportTable(a *apl.Apl, tb apl.Table, t reflect.Type, cb *callbacks) (reflect.Value, error) {
+	s := reflect.MakeSlice(t, tb.Rows, tb.Rows)
+	for _, k := range tb.Keys() {
+		col, ok := tb.At(k).(apl.Array)
+		if ok == false || col.Size() != tb.Rows {
+			return reflect.Value{}, fmt.Errorf("table column %s has wrong size", k.String(apl.Format{}))
+		}
+		for i := 0; i < tb.Rows; i++ {
+			if err := setField(a, s.Index(i), k, col.At(i), cb); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// setField sets the struct field with the name key.
+func setField(a *apl.Apl, s reflect.Value, key, v apl.Value, cb *callbacks) error {
+	name, ok := key.(apl.String)
+	if ok == false {
+		return fmt.Errorf("field name must be a string: %T", key)
+	}
+	f := s.FieldByName(upper(string(name)))
+	if f.IsValid() == false || f.CanSet() == false {
+		return fmt.Errorf("%v: field does not exist: %s", s.Type(), name)
+	}
+	fv, err := export(a, v, f.Type(), cb)
+	if err != nil {
+		return fmt.Errorf("%v.%s: %s", s.Type(), name, err)
+	}
+	f.Set(fv)
+	return nil
+}
+
+// exportAny converts v to it's natural go type for an empty interface.
+func exportAny(a *apl.Apl, v apl.Value, cb *callbacks) (reflect.Value, error) {
+	switch x := v.(type) {
+	case apl.Bool:
+		return reflect.ValueOf(bool(x)), nil
+	case apl.Int:
+		return reflect.ValueOf(int(x)), nil
+	case numbers.Float:
+		return reflect.ValueOf(float64(x)), nil
+	case numbers.Complex:
+		return reflect.ValueOf(complex128(x)), nil
+	case apl.String:
+		return reflect.ValueOf(string(x)), nil
+	case numbers.Time:
+		return reflect.ValueOf(time.Time(x)), nil
+	case Value:
+		return reflect.Value(x), nil
+	case apl.EmptyArray:
+		return reflect.Value{}, nil
+	case apl.Object:
+		return export(a, v, reflect.TypeOf(map[string]interface{}{}), cb)
+	case apl.Array:
+		return export(a, v, reflect.TypeOf([]interface{}{}), cb)
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %T to a go value", v)
+}
+
+// Convert converts a go value to an apl value.
+//
+// Maps are converted to a *Dict, slices of structs to a Table
+// with a column for each field, time.Time and time.Duration to numbers.Time.
+// Go channels are bridged to an apl.Channel.
+// Other structs are returned as an xgo.Value, funcs as an xgo.Function.
 func Convert(v reflect.Value) (apl.Value, error) {
+	switch v.Type() {
+	case timeType:
+		return numbers.Time(v.Interface().(time.Time)), nil
+	case durationType:
+		return numbers.Time(y0.Add(time.Duration(v.Int()))), nil
+	}
+
 	switch v.Kind() {
-	case reflect.Int:
+	case reflect.Bool:
+		return apl.Bool(v.Bool()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return apl.Int(int(v.Int())), nil
 
-	case reflect.Uint:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		return apl.Int(int(v.Uint())), nil
 
-	case reflect.Float64:
+	case reflect.Float32, reflect.Float64:
 		return numbers.Float(v.Float()), nil
 
-	case reflect.Complex128:
+	case reflect.Complex64, reflect.Complex128:
 		return numbers.Complex(v.Complex()), nil
 
 	case reflect.String:
 		return apl.String(v.String()), nil
 
-	case reflect.Slice:
+	case reflect.Slice, reflect.Array:
+		if et := v.Type().Elem(); et.Kind() == reflect.Struct && et != timeType {
+			return convertTable(v)
+		}
 		n := v.Len()
-		ar := apl.NewMixed([]int{n})
-		for i := range ar.Values {
+		values := make([]apl.Value, n)
+		for i := range values {
 			if e, err := Convert(v.Index(i)); err != nil {
 				return nil, err
 			} else {
-				ar.Values[i] = e
+				values[i] = e
+			}
+		}
+		return uniform(values), nil
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		d := apl.Dict{K: make([]apl.Value, len(keys)), M: make(map[apl.Value]apl.Value)}
+		for i, k := range keys {
+			kv, err := Convert(k)
+			if err != nil {
+				return nil, err
+			}
+			ev, err := Convert(v.MapIndex(k))
+			if err != nil {
+				return nil, err
 			}
+			d.K[i] = kv
+			d.M[kv] = ev
 		}
-		return ar, nil
+		f := apl.Format{}
+		sort.Slice(d.K, func(i, j int) bool { return d.K[i].String(f) < d.K[j].String(f) })
+		return &d, nil
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return apl.EmptyArray{}, nil
+		} else if v.Elem().Kind() == reflect.Struct && v.Elem().Type() != timeType {
+			return Value(v), nil
+		}
+		return Convert(v.Elem())
+
+	case reflect.Chan:
+		if v.IsNil() {
+			return apl.EmptyArray{}, nil
+		}
+		return convertChan(v), nil
+
+	case reflect.Func:
+		if v.IsNil() {
+			return apl.EmptyArray{}, nil
+		}
+		return Function{Name: v.Type().String(), Fn: v}, nil
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return apl.EmptyArray{}, nil
+		}
+		return Convert(v.Elem())
 
 	case reflect.Struct:
+		if v.CanAddr() == false {
+			p := reflect.New(v.Type())
+			p.Elem().Set(v)
+			return Value(p), nil
+		}
 		return Value(v.Addr()), nil // TODO: populate
 
 	default:
 		return nil, fmt.Errorf("cannot convert %s to an apl value", v.Kind())
 	}
 }
+
+// convertTable converts a slice of structs to a table.
+// Each exported field is a column.
+func convertTable(v reflect.Value) (apl.Value, error) {
+	t := v.Type().Elem()
+	n := v.Len()
+	d := apl.Dict{M: make(map[apl.Value]apl.Value)}
+	for k := 0; k < t.NumField(); k++ {
+		f := t.Field(k)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		values := make([]apl.Value, n)
+		for i := range values {
+			e, err := Convert(v.Index(i).Field(k))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", f.Name, err)
+			}
+			values[i] = e
+		}
+		key := apl.String(f.Name)
+		d.K = append(d.K, key)
+		if n == 0 {
+			d.M[key] = apl.EmptyArray{}
+		} else {
+			d.M[key] = uniform(values)
+		}
+	}
+	return apl.Table{Dict: &d, Rows: n}, nil
+}
+
+// uniform returns a uniform array for the vector of values if possible, or a mixed array.
+func uniform(values []apl.Value) apl.Array {
+	n := len(values)
+	if n == 0 {
+		return apl.NewMixed([]int{0})
+	}
+	switch values[0].(type) {
+	case apl.Int:
+		ar := apl.IntArray{Dims: []int{n}, Ints: make([]int, n)}
+		for i, v := range values {
+			if x, ok := v.(apl.Int); ok {
+				ar.Ints[i] = int(x)
+			} else {
+				return mixed(values)
+			}
+		}
+		return ar
+	case numbers.Float:
+		ar := numbers.FloatArray{Dims: []int{n}, Floats: make([]float64, n)}
+		for i, v := range values {
+			if x, ok := v.(numbers.Float); ok {
+				ar.Floats[i] = float64(x)
+			} else {
+				return mixed(values)
+			}
+		}
+		return ar
+	case apl.String:
+		ar := apl.StringArray{Dims: []int{n}, Strings: make([]string, n)}
+		for i, v := range values {
+			if x, ok := v.(apl.String); ok {
+				ar.Strings[i] = string(x)
+			} else {
+				return mixed(values)
+			}
+		}
+		return ar
+	case apl.Bool:
+		ar := apl.BoolArray{Dims: []int{n}, Bools: make([]bool, n)}
+		for i, v := range values {
+			if x, ok := v.(apl.Bool); ok {
+				ar.Bools[i] = bool(x)
+			} else {
+				return mixed(values)
+			}
+		}
+		return ar
+	}
+	return mixed(values)
+}
+
+func mixed(values []apl.Value) apl.Array {
+	ar := apl.NewMixed([]int{len(values)})
+	copy(ar.Values, values)
+	return ar
+}
@@ -21,9 +21,11 @@ func (f Function) Copy() apl.Value { return f }
 // If it requires 1 argument, that is taken from the right value.
 // Two arguments may be the right and left argument or a vector of 2 arguments.
 // More than two arguments must be passed in a vector of the right size.
+// The last argument of a variadic function is passed as a slice.
 // If the function returns an error as the last value, it is checked and returned.
 // Otherwise, or if the error is nil the result is converted and returned.
 // More than one result will be returned as a List.
+// A failing callback, that cannot return an error, is returned as an error.
 func (f Function) Call(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
 	errarg := func(i int, err error) error {
 		return fmt.Errorf("function %s argument %d: %s", f.Name, i+1, err)
@@ -31,19 +33,20 @@ func (f Function) Call(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
 	t := f.Fn.Type()
 	args := t.NumIn()
 	in := make([]reflect.Value, args)
+	var cb callbacks
 	var err error
 	if args == 0 {
 	} else if args == 1 {
-		in[0], err = export(R, t.In(0))
+		in[0], err = export(a, R, t.In(0), &cb)
 		if err != nil {
 			return nil, errarg(0, err)
 		}
 	} else if args == 2 && L != nil {
-		in[0], err = export(R, t.In(0))
+		in[0], err = export(a, R, t.In(0), &cb)
 		if err != nil {
 			return nil, errarg(0, err)
 		}
-		in[1], err = export(L, t.In(1))
+		in[1], err = export(a, L, t.In(1), &cb)
 		if err != nil {
 			return nil, errarg(1, err)
 		}
@@ -60,14 +63,22 @@ func (f Function) Call(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
 			return nil, fmt.Errorf("function %s requires %d arguments, R has size %d", f.Name, args, n)
 		} else {
 			for i := 0; i < args; i++ {
-				in[i], err = export(ar.At(i), t.In(i))
+				in[i], err = export(a, ar.At(i), t.In(i), &cb)
 				if err != nil {
 					return nil, errarg(i, err)
 				}
 			}
 		}
 	}
-	out := f.Fn.Call(in)
+	var out []reflect.Value
+	if t.IsVariadic() {
+		out = f.Fn.CallSlice(in)
+	} else {
+		out = f.Fn.Call(in)
+	}
+	if err := cb.Err(); err != nil {
+		return nil, err
+	}
 
 	// Test if the last output value is an error, check and remove it.
 	if len(out) > 0 {
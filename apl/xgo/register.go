@@ -3,7 +3,9 @@ package xgo
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/ktye/iv/apl"
 )
@@ -18,6 +20,20 @@ func Register(a *apl.Apl, name string) {
 		"i":      New(reflect.TypeOf(I(0))),
 		"source": source{},
 		"echo":   echo{},
+		"rows":   Function{Name: "Rows", Fn: reflect.ValueOf(Rows)},
+		"sums":   Function{Name: "Sums", Fn: reflect.ValueOf(Sums)},
+		"count":  Function{Name: "Count", Fn: reflect.ValueOf(Count)},
+		"total":  Function{Name: "Total", Fn: reflect.ValueOf(Total)},
+		"after":  Function{Name: "After", Fn: reflect.ValueOf(After)},
+		"deref":  Function{Name: "Deref", Fn: reflect.ValueOf(Deref)},
+		"apply":  Function{Name: "Apply", Fn: reflect.ValueOf(Apply)},
+		"sortby": Function{Name: "SortBy", Fn: reflect.ValueOf(SortBy)},
+		"check":  Function{Name: "Check", Fn: reflect.ValueOf(Check)},
+		"fields": Function{Name: "FieldsFunc", Fn: reflect.ValueOf(strings.FieldsFunc)},
+		"gen":    Function{Name: "Generate", Fn: reflect.ValueOf(Generate)},
+		"sum":    Function{Name: "Sum", Fn: reflect.ValueOf(Sum)},
+		"adder":  Function{Name: "Adder", Fn: reflect.ValueOf(Adder)},
+		"fill":   Function{Name: "Fill", Fn: reflect.ValueOf(Fill)},
 	}
 	a.RegisterPackage("go", pkg)
 }
@@ -54,6 +70,118 @@ func (s S) Sum() int {
 	return s.A + s.B
 }
 
+// Rows returns a slice of structs, which is converted to a table.
+func Rows(n int) []S {
+	s := make([]S, n)
+	for i := range s {
+		s[i] = S{A: i, B: i * i}
+	}
+	return s
+}
+
+// Sums returns the sum of each row, it accepts a table.
+func Sums(s []S) []int {
+	r := make([]int, len(s))
+	for i := range s {
+		r[i] = s[i].Sum()
+	}
+	return r
+}
+
+// Count counts the words, the result is converted to a dict.
+func Count(words []string) map[string]int {
+	m := make(map[string]int)
+	for _, w := range words {
+		m[w]++
+	}
+	return m
+}
+
+// Total adds the values of a map, it accepts a dict.
+func Total(m map[string]float32) float32 {
+	var s float32
+	for _, v := range m {
+		s += v
+	}
+	return s
+}
+
+// After adds a duration to a time.
+func After(d time.Duration, t time.Time) time.Time {
+	return t.Add(d)
+}
+
+// Deref returns the value of a pointer and if it was true.
+func Deref(p *int64) (uint, bool) {
+	return uint(*p), *p != 0
+}
+
+// Apply calls f for each value, an apl function is converted to f.
+func Apply(f func(int) int, v []int) []int {
+	r := make([]int, len(v))
+	for i := range v {
+		r[i] = f(v[i])
+	}
+	return r
+}
+
+// SortBy sorts with a less function of two arguments, which is called dyadically.
+func SortBy(v []string, less func(a, b string) bool) []string {
+	sort.SliceStable(v, func(i, j int) bool { return less(v[i], v[j]) })
+	return v
+}
+
+// Check calls f with x and returns it's error, an apl error is passed in the error result.
+func Check(f func(int) (int, error), x int) string {
+	if _, err := f(x); err != nil {
+		return "error: " + err.Error()
+	}
+	return "ok"
+}
+
+// Generate returns a channel that is converted to an apl.Channel.
+func Generate(n int) <-chan int {
+	c := make(chan int)
+	go func() {
+		for i := 0; i < n; i++ {
+			c <- i
+		}
+		close(c)
+	}()
+	return c
+}
+
+// Sum adds all values received from a channel, it accepts an apl.Channel.
+func Sum(c <-chan float64) float64 {
+	s := 0.0
+	for f := range c {
+		s += f
+	}
+	return s
+}
+
+// Adder returns a send-only channel and sends the total of all values to the result channel, when it is closed.
+func Adder() (chan<- int, <-chan int) {
+	in, out := make(chan int), make(chan int, 1)
+	go func() {
+		s := 0
+		for i := range in {
+			s += i
+		}
+		out <- s
+		close(out)
+	}()
+	return in, out
+}
+
+// Fill sends n values to an apl.Channel.
+func Fill(c chan<- string, n int) {
+	for i := 0; i < n; i++ {
+		c <- strings.Repeat("x", i+1)
+	}
+	close(c)
+}
+
 // source returns a Channel to pull numbers from.
 // It stops if the max value is reached or the channel is closed.
 // It is used for demonstrating apl.Channel.
@@ -122,7 +122,7 @@ func (v Value) Set(key apl.Value, fv apl.Value) error {
 	if sf == zero {
 		return fmt.Errorf("%v: field does not exist: %s", val.Type(), field)
 	}
-	sv, err := export(fv, sf.Type())
+	sv, err := export(nil, fv, sf.Type(), nil)
 	if err != nil {
 		return err
 	}
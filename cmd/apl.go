@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/ktye/iv/apl"
+	"github.com/ktye/iv/apl/scan"
 )
 
 // Apl runs the interpreter in file mode if arguments are given, otherwise as a repl.
@@ -37,6 +38,9 @@ func Apl(a *apl.Apl, stdin io.Reader, args []string) error {
 	for scanner.Scan() {
 		s := scanner.Text()
 		if err := a.ParseAndEval(s); err != nil {
+			if e, ok := err.(scan.PosError); ok {
+				fmt.Println(e.Caret(s))
+			}
 			fmt.Println(err)
 		}
 	}
@@ -1,15 +1,226 @@
 package cmd
 
 import (
+	"bufio"
+	"flag"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"strings"
 
 	"github.com/ktye/iv/apl"
 )
 
+// Iv runs the program p on the data read from stdin.
 func Iv(a *apl.Apl, p string, w io.Writer) error {
+	return IvArgs(a, []string{"--", p}, w)
+}
+
+// IvArgs runs the program given by the command line arguments on the data read from stdin.
+// Options are parsed up to the first argument that is not an option, or up to --.
+// The program may start with -, e.g. -+/¨r 1.
+//
+// Options:
+//	-F sep	field separator for records, default is white space, \t is a tab
+//	-H	the first line names the columns of records
+//	-f file	read the program from a file, the remaining arguments are input files
+//	-v N=v	assign the variable N, v is a number or a string
+//	-B expr	evaluate expr before the program
+//	-E expr	evaluate expr after the program
+// In a program file, lines starting with BEGIN or END are evaluated before or after the program.
+func IvArgs(a *apl.Apl, args []string, w io.Writer) error {
+	var rc Records
+	var file string
+	var vars, begin, end multiFlag
+	fs := flag.NewFlagSet("iv", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.StringVar(&rc.Sep, "F", "", "field separator")
+	fs.BoolVar(&rc.Header, "H", false, "header line")
+	fs.StringVar(&file, "f", "", "program file")
+	fs.Var(&vars, "v", "variable assignment")
+	fs.Var(&begin, "B", "begin expression")
+	fs.Var(&end, "E", "end expression")
+	if err := fs.Parse(splitFlags(args)); err != nil {
+		return fmt.Errorf("iv: %s", err)
+	}
+	rc.Sep = strings.Replace(rc.Sep, `\t`, "\t", -1)
+
+	var prog string
+	var files inputFiles
+	if file != "" {
+		b, e, p, err := readProgram(file)
+		if err != nil {
+			return err
+		}
+		begin, end, prog = append(b, begin...), append(e, end...), p
+		files = fs.Args()
+	} else if fs.NArg() == 0 {
+		return fmt.Errorf("iv: program is missing")
+	} else {
+		prog = strings.Join(fs.Args(), " ")
+	}
+
 	a.SetOutput(w)
-	if err := a.ParseAndEval(`r←{<⍤⍵ io→r 0}⋄s←{⍵⍴<⍤0 io→r 0}`); err != nil {
+	a.RegisterPackage("iv", map[string]apl.Value{
+		"records": apl.ToFunction(rc.records),
+		"r":       apl.ToFunction(files.read),
+	})
+	in := "io→r 0"
+	if len(files) > 0 {
+		in = "iv→r 0"
+	}
+	if err := a.ParseAndEval(strings.Replace(`r←{<⍤⍵ IN}⋄s←{⍵⍴<⍤0 IN}⋄d←{iv→records IN}⋄t←{⍵ iv→records IN}`, "IN", in, -1)); err != nil {
+		return err
+	}
+	for _, s := range vars {
+		i := strings.Index(s, "=")
+		if i < 1 {
+			return fmt.Errorf("iv: -v expects NAME=value: %s", s)
+		}
+		if err := a.Assign(s[:i], field(a, s[i+1:])); err != nil {
+			return err
+		}
+	}
+	for _, s := range begin {
+		if err := a.ParseAndEval(s); err != nil {
+			return err
+		}
+	}
+	if file != "" {
+		if err := a.EvalFile(strings.NewReader(prog), file); err != nil {
+			return err
+		}
+	} else if err := a.ParseAndEval(prog); err != nil {
 		return err
 	}
-	return a.ParseAndEval(p)
+	for _, s := range end {
+		if err := a.ParseAndEval(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ivFlags are the options of iv, the value is true if the option has an argument.
+var ivFlags = map[string]bool{"F": true, "H": false, "f": true, "v": true, "B": true, "E": true}
+
+// splitFlags inserts -- after the leading options,
+// such that flag parsing stops before a program that starts with -.
+// A separator that is attached to -F is split, as in awk: -F, or -F:
+func splitFlags(args []string) []string {
+	var flags []string
+	for i := 0; i < len(args); i++ {
+		s := args[i]
+		if s == "--" {
+			return append(flags, args[i:]...)
+		} else if strings.HasPrefix(s, "-F") && len(s) > 2 && s[2] != '=' {
+			flags = append(flags, "-F", s[2:])
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "-")
+		value := false
+		if k := strings.Index(name, "="); k >= 0 {
+			name, value = name[:k], true
+		}
+		arg, ok := ivFlags[name]
+		if strings.HasPrefix(s, "-") == false || ok == false {
+			return append(append(flags, "--"), args[i:]...)
+		}
+		flags = append(flags, s)
+		if arg && value == false && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	return flags
+}
+
+// multiFlag collects the values of a flag that is given multiple times.
+type multiFlag []string
+
+func (m *multiFlag) String() string     { return strings.Join(*m, " ") }
+func (m *multiFlag) Set(s string) error { *m = append(*m, s); return nil }
+
+// readProgram reads a program file and separates BEGIN and END lines.
+// Empty lines in the program are kept to preserve line numbers.
+func readProgram(file string) (begin, end []string, prog string, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		t := strings.TrimSpace(line)
+		if strings.HasPrefix(t, "BEGIN ") {
+			begin = append(begin, t[6:])
+			line = ""
+		} else if strings.HasPrefix(t, "END ") {
+			end = append(end, t[4:])
+			line = ""
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return begin, end, b.String(), s.Err()
+}
+
+// inputFiles are read in order as a single stream.
+type inputFiles []string
+
+// read returns a channel of the lines of all input files.
+func (files inputFiles) read(a *apl.Apl, _, R apl.Value) (apl.Value, error) {
+	if fd, ok := R.(apl.Int); ok == false || fd != 0 {
+		return nil, fmt.Errorf("iv r: argument must be 0")
+	}
+	var r multiReader
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		r.files = append(r.files, f)
+	}
+	return apl.LineReader(&r), nil
+}
+
+// multiReader concatenates files.
+// A newline is inserted after a file that does not end with one.
+type multiReader struct {
+	files []*os.File
+	last  byte
+}
+
+func (m *multiReader) Read(p []byte) (int, error) {
+	for len(m.files) > 0 && len(p) > 0 {
+		n, err := m.files[0].Read(p)
+		if n > 0 {
+			m.last = p[n-1]
+		}
+		if err != io.EOF {
+			return n, err
+		} else if n > 0 {
+			return n, nil
+		}
+		m.files[0].Close()
+		m.files = m.files[1:]
+		if m.last != '\n' && m.last != 0 {
+			p[0], m.last = '\n', '\n'
+			return 1, nil
+		}
+	}
+	return 0, io.EOF
+}
+
+func (m *multiReader) Close() error {
+	for _, f := range m.files {
+		f.Close()
+	}
+	m.files = nil
+	return nil
 }
@@ -1,14 +1,14 @@
 // APL stream processor.
 //
 // Usage
-//	cat data | iv COMMANDS
+//	cat data | iv [-F sep] [-H] [-v N=v] [-B expr] [-E expr] COMMANDS
+//	iv [-F sep] [-H] [-v N=v] -f PROGRAM FILES...
 package main
 
 import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 
 	"github.com/ktye/iv/apl"
 	"github.com/ktye/iv/apl/numbers"
@@ -24,7 +24,7 @@ func main() {
 		fatal(fmt.Errorf("arguments expected"))
 	}
 	a := newApl(stdin)
-	fatal(cmd.Iv(a, strings.Join(os.Args[1:], " "), os.Stdout))
+	fatal(cmd.IvArgs(a, os.Args[1:], os.Stdout))
 }
 
 func newApl(r io.ReadCloser) *apl.Apl {
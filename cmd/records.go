@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ktye/iv/apl"
+)
+
+// Records splits input lines into fields, like awk.
+//
+// Each line is a record. Fields that parse as numbers are numbers, others are strings.
+// If Header is set, the first line names the columns,
+// otherwise they are named f1, f2, ...
+// Empty lines are skipped.
+type Records struct {
+	Sep    string // Sep is the field separator, empty splits at white space.
+	Header bool   // Header is set if the first line contains the column names.
+}
+
+// records converts the channel of lines R to a channel of records.
+// Without a left argument, each record is a dict.
+// Otherwise records are collected to tables with L rows, or a single table if L is 0.
+func (rc Records) records(a *apl.Apl, L, R apl.Value) (apl.Value, error) {
+	c, ok := R.(apl.Channel)
+	if ok == false {
+		return nil, fmt.Errorf("iv records: right argument must be a channel: %T", R)
+	}
+	batch := -1
+	if L != nil {
+		n, ok := L.(apl.Int)
+		if ok == false || n < 0 {
+			return nil, fmt.Errorf("iv records: left argument must be a positive integer or 0")
+		}
+		batch = int(n)
+	}
+
+	out := apl.NewChannel()
+	go func() {
+		defer close(out[0])
+		send := func(v apl.Value) bool {
+			select {
+			case out[0] <- v:
+				return true
+			case <-out[1]:
+				c.Close()
+				return false
+			}
+		}
+		fail := func(err error) {
+			send(apl.Error{E: err})
+			c.Close()
+		}
+
+		var names []apl.Value
+		var rows [][]apl.Value
+		line := 0
+		for v := range c[0] {
+			line++
+			s, ok := v.(apl.String)
+			if ok == false {
+				if e, ok := v.(apl.Error); ok {
+					fail(e.E)
+				} else {
+					fail(fmt.Errorf("iv records: line %d: expected a string: %T", line, v))
+				}
+				return
+			}
+			if strings.TrimSpace(string(s)) == "" {
+				continue
+			}
+			f := rc.fields(string(s))
+			if names == nil && rc.Header {
+				names = make([]apl.Value, len(f))
+				for i := range f {
+					names[i] = apl.String(strings.TrimSpace(f[i]))
+				}
+				continue
+			} else if names == nil || (rc.Header == false && batch < 0) {
+				names = columns(len(f))
+			}
+			if len(f) != len(names) {
+				fail(fmt.Errorf("iv records: line %d: expected %d fields, got %d", line, len(names), len(f)))
+				return
+			}
+
+			values := make([]apl.Value, len(f))
+			for i := range f {
+				values[i] = field(a, f[i])
+			}
+			if batch < 0 {
+				d := apl.Dict{K: names, M: make(map[apl.Value]apl.Value)}
+				for i, k := range names {
+					d.M[k] = values[i]
+				}
+				if send(&d) == false {
+					return
+				}
+				continue
+			}
+			rows = append(rows, values)
+			if len(rows) == batch {
+				if send(table(a, names, rows)) == false {
+					return
+				}
+				rows = nil
+			}
+		}
+		if len(rows) > 0 {
+			send(table(a, names, rows))
+		}
+	}()
+	return out, nil
+}
+
+// fields splits a line at the separator.
+func (rc Records) fields(s string) []string {
+	if rc.Sep == "" {
+		return strings.Fields(s)
+	}
+	return strings.Split(s, rc.Sep)
+}
+
+// columns returns the default column names f1, f2, ...
+func columns(n int) []apl.Value {
+	names := make([]apl.Value, n)
+	for i := range names {
+		names[i] = apl.String("f" + strconv.Itoa(i+1))
+	}
+	return names
+}
+
+// field parses a number, or returns the field as a string.
+// A minus sign is accepted in place of ¯.
+func field(a *apl.Apl, s string) apl.Value {
+	t := strings.TrimSpace(s)
+	if strings.HasPrefix(t, "-") {
+		t = "¯" + t[1:]
+	}
+	t = strings.Replace(strings.Replace(t, "e-", "e¯", 1), "E-", "E¯", 1)
+	if n, err := a.Tower.Parse(t); err == nil {
+		return n.Number
+	}
+	return apl.String(s)
+}
+
+// table converts rows of values to a table with the column names.
+func table(a *apl.Apl, names []apl.Value, rows [][]apl.Value) apl.Table {
+	d := apl.Dict{K: names, M: make(map[apl.Value]apl.Value)}
+	for j, k := range names {
+		col := apl.MixedArray{Dims: []int{len(rows)}, Values: make([]apl.Value, len(rows))}
+		for i := range rows {
+			col.Values[i] = rows[i][j]
+		}
+		d.M[k] = a.UnifyArray(col)
+	}
+	return apl.Table{Dict: &d, Rows: len(rows)}
+}
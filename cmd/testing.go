@@ -77,6 +77,7 @@ func testIvFile(newapl func(io.ReadCloser) *apl.Apl, file string) error {
 	defer f.Close()
 
 	// First line in each iv test file is the program with a comment.
+	// It may start with options.
 	r := bufio.NewReader(f)
 	prog, err := readline(r)
 	if err != nil {
@@ -84,7 +85,7 @@ func testIvFile(newapl func(io.ReadCloser) *apl.Apl, file string) error {
 	}
 
 	var out bytes.Buffer
-	if err := Iv(newapl(ioutil.NopCloser(r)), prog[1:], &out); err != nil {
+	if err := IvArgs(newapl(ioutil.NopCloser(r)), strings.Fields(prog[1:]), &out); err != nil {
 		return err
 	}
 	return compareOut(out.Bytes(), file)
@@ -0,0 +1,198 @@
+// Xgo generates a Register function for an APL package that wraps a go package.
+//
+// Usage
+//	xgo [-o FILE] [-p PACKAGE] [-n NAME] IMPORTPATH
+//
+// It is meant to be called by go generate:
+//	//go:generate go run github.com/ktye/iv/cmd/xgo -o register.go math
+//
+// Every exported function becomes an xgo.Function,
+// every exported struct type a constructor using xgo.New
+// and every exported method a function with the receiver as the first argument,
+// named type_method.
+// Names are lowercased.
+// Generic functions and types are skipped.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+func main() {
+	out := flag.String("o", "", "output file, default stdout")
+	pkg := flag.String("p", os.Getenv("GOPACKAGE"), "package name of the output file, default $GOPACKAGE or the name of the go package")
+	name := flag.String("n", "", "default APL package name, default the name of the go package")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: xgo [-o FILE] [-p PACKAGE] [-n NAME] IMPORTPATH")
+		os.Exit(2)
+	}
+
+	var buf bytes.Buffer
+	if err := generate(&buf, flag.Arg(0), *pkg, *name); err != nil {
+		fatal(err)
+	}
+	if *out == "" {
+		os.Stdout.Write(buf.Bytes())
+	} else if err := ioutil.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// entry is a value in the generated package map.
+type entry struct {
+	key, expr string
+}
+
+// generate writes the formatted source of the wrapper package for the import path.
+func generate(w io.Writer, importPath, pkgName, aplName string) error {
+	p, err := load(importPath)
+	if err != nil {
+		return err
+	}
+	if pkgName == "" {
+		pkgName = p.Name()
+	}
+	if aplName == "" {
+		aplName = p.Name()
+	}
+
+	// The go package is imported under it's name, unless that collides with the generated imports.
+	q := p.Name()
+	imp := fmt.Sprintf("%q", importPath)
+	if q == "apl" || q == "xgo" || q == "reflect" {
+		q = "go" + q
+		imp = q + " " + imp
+	}
+
+	entries, skipped := wrap(p, q)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by cmd/xgo %s; DO NOT EDIT.\n\n", importPath)
+	fmt.Fprintf(&b, "// Package %s provides the go package %s.\n", pkgName, importPath)
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+	fmt.Fprintf(&b, "import (\n\t\"reflect\"\n\t%s\n\n\t\"github.com/ktye/iv/apl\"\n\t\"github.com/ktye/iv/apl/xgo\"\n)\n\n", imp)
+	fmt.Fprintf(&b, "// Register adds the %s package to the interpreter.\n", importPath)
+	fmt.Fprintf(&b, "func Register(a *apl.Apl, name string) {\n\tif name == \"\" {\n\t\tname = %q\n\t}\n", aplName)
+	fmt.Fprintf(&b, "\tpkg := map[string]apl.Value{\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\t\t%q: %s,\n", e.key, e.expr)
+	}
+	fmt.Fprintf(&b, "\t}\n\ta.RegisterPackage(name, pkg)\n}\n")
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "\n// Skipped because of name collisions:\n")
+		for _, s := range skipped {
+			fmt.Fprintf(&b, "//\t%s\n", s)
+		}
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// load type checks the package from source.
+func load(importPath string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	p, err := importer.ForCompiler(fset, "source", nil).Import(importPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", importPath, err)
+	}
+	return p, nil
+}
+
+// wrap returns the sorted package entries for all exported functions, struct types and methods.
+// The package is referred to by q.
+func wrap(p *types.Package, q string) ([]entry, []string) {
+	m := make(map[string]string)
+	var skipped []string
+	add := func(key, goname, expr string) {
+		if _, ok := m[key]; ok {
+			skipped = append(skipped, goname)
+			return
+		}
+		m[key] = expr
+	}
+
+	scope := p.Scope()
+	names := scope.Names()
+	sort.Strings(names)
+	for _, name := range names {
+		obj := scope.Lookup(name)
+		if obj.Exported() == false {
+			continue
+		}
+		if f, ok := obj.(*types.Func); ok {
+			if generic(f) {
+				continue
+			}
+			add(lower(name), name, fmt.Sprintf("xgo.Function{Name: %q, Fn: reflect.ValueOf(%s.%s)}", name, q, name))
+		}
+	}
+	for _, name := range names {
+		obj := scope.Lookup(name)
+		tn, ok := obj.(*types.TypeName)
+		if ok == false || obj.Exported() == false || tn.IsAlias() {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if ok == false || named.TypeParams().Len() > 0 {
+			continue
+		}
+		if _, ok := named.Underlying().(*types.Struct); ok {
+			add(lower(name), name, fmt.Sprintf("xgo.New(reflect.TypeOf(%s.%s{}))", q, name))
+		}
+		if _, ok := named.Underlying().(*types.Interface); ok {
+			continue
+		}
+		ms := types.NewMethodSet(types.NewPointer(named))
+		for i := 0; i < ms.Len(); i++ {
+			f := ms.At(i).Obj().(*types.Func)
+			if f.Exported() == false || len(ms.At(i).Index()) > 1 {
+				continue // promoted methods are wrapped with the embedded type
+			}
+			recv := fmt.Sprintf("%s.%s", q, name)
+			if _, ptr := f.Type().(*types.Signature).Recv().Type().(*types.Pointer); ptr {
+				recv = "(*" + recv + ")"
+			}
+			add(lower(name)+"_"+lower(f.Name()), name+"."+f.Name(), fmt.Sprintf("xgo.Function{Name: %q, Fn: reflect.ValueOf(%s.%s)}", name+"."+f.Name(), recv, f.Name()))
+		}
+	}
+
+	entries := make([]entry, 0, len(m))
+	for k, v := range m {
+		entries = append(entries, entry{k, v})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries, skipped
+}
+
+func generic(f *types.Func) bool {
+	return f.Type().(*types.Signature).TypeParams().Len() > 0
+}
+
+// lower lowercases the name, such as the hand-written strings package: EqualFold becomes equalfold.
+func lower(s string) string {
+	return strings.Map(unicode.ToLower, s)
+}
+
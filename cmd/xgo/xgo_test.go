@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestXgo(t *testing.T) {
+	var b bytes.Buffer
+	if err := generate(&b, "strings", "gostrings", "s"); err != nil {
+		t.Fatal(err)
+	}
+	src := b.String()
+	for _, s := range []string{
+		"package gostrings\n",
+		`name = "s"`,
+		`"toupper":`,
+		`xgo.Function{Name: "ToUpper", Fn: reflect.ValueOf(strings.ToUpper)}`,
+		`"builder":`,
+		`xgo.New(reflect.TypeOf(strings.Builder{}))`,
+		`"builder_writestring":`,
+		`reflect.ValueOf((*strings.Builder).WriteString)`,
+	} {
+		if strings.Contains(src, s) == false {
+			t.Fatalf("generated source does not contain %q:\n%s", s, src)
+		}
+	}
+	if strings.Contains(src, `"reader_reset"`) == false {
+		t.Fatal("methods of struct types are missing")
+	}
+
+	if err := generate(&b, "does/not/exist", "", ""); err == nil {
+		t.Fatal("expected error for missing package")
+	}
+}
+
+// The generated source compiles.
+func TestXgoTypes(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	for _, pkg := range []string{"strings", "math", "bufio", "time"} {
+		var b bytes.Buffer
+		if err := generate(&b, pkg, "x"+pkg, ""); err != nil {
+			t.Fatal(err)
+		}
+		f, err := parser.ParseFile(fset, pkg+".go", b.Bytes(), 0)
+		if err != nil {
+			t.Fatalf("%s: %s", pkg, err)
+		}
+		if _, err := conf.Check("x"+pkg, fset, []*ast.File{f}, nil); err != nil {
+			t.Fatalf("%s: %s", pkg, err)
+		}
+	}
+}